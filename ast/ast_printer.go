@@ -23,8 +23,16 @@ func (ap *AstPrinter) Print(node Node) string {
 		return ap.visitLetStatement(n)
 	case *ReturnStatement:
 		return ap.visitReturnStatement(n)
+	case *SleepStatement:
+		return ap.visitSleepStatement(n)
+	case *ExitStatement:
+		return ap.visitExitStatement(n)
+	case *AssertStatement:
+		return ap.visitAssertStatement(n)
 	case *ExpressionStatement:
 		return ap.visitExpressionStatement(n)
+	case *EmptyStatement:
+		return ap.visitEmptyStatement(n)
 	case *BlockStatement:
 		return ap.visitBlockStatement(n)
 	case *IfStatement:
@@ -37,10 +45,16 @@ func (ap *AstPrinter) Print(node Node) string {
 		return ap.visitPrefixExpression(n)
 	case *InfixExpression:
 		return ap.visitInfixExpression(n)
+	case *PostfixExpression:
+		return ap.visitPostfixExpression(n)
+	case *CommaExpression:
+		return ap.visitCommaExpression(n)
 	case *CallExpression:
 		return ap.visitCallExpression(n)
 	case *IndexExpression:
 		return ap.visitIndexExpression(n)
+	case *EmitExpression:
+		return ap.visitEmitExpression(n)
 	case *IntegerLiteral:
 		return ap.visitIntegerLiteral(n)
 	case *FloatLiteral:
@@ -71,14 +85,72 @@ func (ap *AstPrinter) Print(node Node) string {
 		return ap.visitIncludeDirective(n)
 	case *DefineDirective:
 		return ap.visitDefineDirective(n)
-	case *IfDefDirective:
-		return ap.visitIfDefDirective(n)
+	case *ConditionalDirective:
+		return ap.visitConditionalDirective(n)
+	case *EmitDirective:
+		return ap.visitEmitDirective(n)
+	case *PragmaDirective:
+		return ap.visitPragmaDirective(n)
+	case *EndInputDirective:
+		return ap.visitEndInputDirective(n)
+	case *UndefDirective:
+		return ap.visitUndefDirective(n)
+	case *ErrorDirective:
+		return ap.visitErrorDirective(n)
+	case *WarningDirective:
+		return ap.visitWarningDirective(n)
+	case *AssertDirective:
+		return ap.visitAssertDirective(n)
+	case *LineDirective:
+		return ap.visitLineDirective(n)
+	case *FileDirective:
+		return ap.visitFileDirective(n)
+	case *SectionDirective:
+		return ap.visitSectionDirective(n)
 	case *NativeFunctionDeclaration:
 		return ap.visitNativeFunctionDeclaration(n)
-	case *StateDeclaration:
-		return ap.visitStateDeclaration(n)
+	case *StateStatement:
+		return ap.visitStateStatement(n)
 	case *FunctionDeclaration:
 		return ap.visitFunctionDeclaration(n)
+	case *HookDeclaration:
+		return ap.visitHookDeclaration(n)
+	case *ForeachStatement:
+		return ap.visitForeachStatement(n)
+	case *IteratorDeclaration:
+		return ap.visitIteratorDeclaration(n)
+	case *TimerDeclaration:
+		return ap.visitTimerDeclaration(n)
+	case *TimerCallExpression:
+		return ap.visitTimerCallExpression(n)
+	case *StopExpression:
+		return ap.visitStopExpression(n)
+	case *InlineDeclaration:
+		return ap.visitInlineDeclaration(n)
+	case *UsingExpression:
+		return ap.visitUsingExpression(n)
+	case *RemoteFunctionDeclaration:
+		return ap.visitRemoteFunctionDeclaration(n)
+	case *LoadTextDeclaration:
+		return ap.visitLoadTextDeclaration(n)
+	case *TextIdentifier:
+		return ap.visitTextIdentifier(n)
+	case *YieldStatement:
+		return ap.visitYieldStatement(n)
+	case *InlineReturnStatement:
+		return ap.visitInlineReturnStatement(n)
+	case *SpecialIdentifier:
+		return ap.visitSpecialIdentifier(n)
+	case *VarArgsExpression:
+		return ap.visitVarArgsExpression(n)
+	case *CommandDeclaration:
+		return ap.visitCommandDeclaration(n)
+	case *SymbolOperatorExpression:
+		return ap.visitSymbolOperatorExpression(n)
+	case *StaticAssertExpression:
+		return ap.visitStaticAssertExpression(n)
+	case *PragmaExpression:
+		return ap.visitPragmaExpression(n)
 	default:
 		return fmt.Sprintf("Unknown node type: %T", n)
 	}
@@ -109,10 +181,32 @@ func (ap *AstPrinter) visitReturnStatement(rs *ReturnStatement) string {
 	return fmt.Sprintf("ReturnStatement(Value: %s)", ap.Print(rs.ReturnValue))
 }
 
+func (ap *AstPrinter) visitSleepStatement(ss *SleepStatement) string {
+	if ss.Value == nil {
+		return "SleepStatement"
+	}
+	return fmt.Sprintf("SleepStatement(Value: %s)", ap.Print(ss.Value))
+}
+
+func (ap *AstPrinter) visitExitStatement(es *ExitStatement) string {
+	if es.Value == nil {
+		return "ExitStatement"
+	}
+	return fmt.Sprintf("ExitStatement(Value: %s)", ap.Print(es.Value))
+}
+
+func (ap *AstPrinter) visitAssertStatement(as *AssertStatement) string {
+	return fmt.Sprintf("AssertStatement(Condition: %s)", ap.Print(as.Condition))
+}
+
 func (ap *AstPrinter) visitExpressionStatement(es *ExpressionStatement) string {
 	return fmt.Sprintf("ExpressionStatement(%s)", ap.Print(es.Expression))
 }
 
+func (ap *AstPrinter) visitEmptyStatement(es *EmptyStatement) string {
+	return "EmptyStatement"
+}
+
 func (ap *AstPrinter) visitBlockStatement(bs *BlockStatement) string {
 	var out strings.Builder
 	out.WriteString("BlockStatement\n")
@@ -166,18 +260,24 @@ func (ap *AstPrinter) visitForStatement(fs *ForStatement) string {
 	var out strings.Builder
 	out.WriteString("ForStatement\n")
 	ap.indentLevel++
-	out.WriteString(ap.indent())
-	out.WriteString("Init: ")
-	out.WriteString(ap.Print(fs.Init))
-	out.WriteString("\n")
-	out.WriteString(ap.indent())
-	out.WriteString("Condition: ")
-	out.WriteString(ap.Print(fs.Condition))
-	out.WriteString("\n")
-	out.WriteString(ap.indent())
-	out.WriteString("Update: ")
-	out.WriteString(ap.Print(fs.Update))
-	out.WriteString("\n")
+	if fs.Init != nil {
+		out.WriteString(ap.indent())
+		out.WriteString("Init: ")
+		out.WriteString(ap.Print(fs.Init))
+		out.WriteString("\n")
+	}
+	if fs.Condition != nil {
+		out.WriteString(ap.indent())
+		out.WriteString("Condition: ")
+		out.WriteString(ap.Print(fs.Condition))
+		out.WriteString("\n")
+	}
+	if fs.Update != nil {
+		out.WriteString(ap.indent())
+		out.WriteString("Update: ")
+		out.WriteString(ap.Print(fs.Update))
+		out.WriteString("\n")
+	}
 	out.WriteString(ap.indent())
 	out.WriteString("Body: ")
 	out.WriteString(ap.Print(fs.Body))
@@ -193,6 +293,23 @@ func (ap *AstPrinter) visitInfixExpression(ie *InfixExpression) string {
 	return fmt.Sprintf("InfixExpression(Left: %s, Operator: %s, Right: %s)", ap.Print(ie.Left), ie.Operator, ap.Print(ie.Right))
 }
 
+func (ap *AstPrinter) visitPostfixExpression(pe *PostfixExpression) string {
+	return fmt.Sprintf("PostfixExpression(Left: %s, Operator: %s)", ap.Print(pe.Left), pe.Operator)
+}
+
+func (ap *AstPrinter) visitCommaExpression(ce *CommaExpression) string {
+	var out strings.Builder
+	out.WriteString("CommaExpression(")
+	for i, expr := range ce.Expressions {
+		if i > 0 {
+			out.WriteString(", ")
+		}
+		out.WriteString(ap.Print(expr))
+	}
+	out.WriteString(")")
+	return out.String()
+}
+
 func (ap *AstPrinter) visitCallExpression(ce *CallExpression) string {
 	var out strings.Builder
 	out.WriteString("CallExpression\n")
@@ -217,6 +334,38 @@ func (ap *AstPrinter) visitIndexExpression(ie *IndexExpression) string {
 	return fmt.Sprintf("IndexExpression(Left: %s, Index: %s)", ap.Print(ie.Left), ap.Print(ie.Index))
 }
 
+func (ap *AstPrinter) visitEmitExpression(ee *EmitExpression) string {
+	var out strings.Builder
+	out.WriteString("EmitExpression\n")
+	ap.indentLevel++
+	for _, in := range ee.Instructions {
+		out.WriteString(ap.indent())
+		out.WriteString(ap.printEmitInstruction(in))
+		out.WriteString("\n")
+	}
+	ap.indentLevel--
+	return out.String()
+}
+
+func (ap *AstPrinter) visitSymbolOperatorExpression(so *SymbolOperatorExpression) string {
+	return fmt.Sprintf("SymbolOperatorExpression(%s %s)", so.TokenLiteral(), so.Symbol.Value)
+}
+
+func (ap *AstPrinter) visitStaticAssertExpression(sa *StaticAssertExpression) string {
+	if sa.Message == nil {
+		return fmt.Sprintf("StaticAssertExpression(%s, Condition: %s)", sa.TokenLiteral(), ap.Print(sa.Condition))
+	}
+	return fmt.Sprintf("StaticAssertExpression(%s, Condition: %s, Message: %s)", sa.TokenLiteral(), ap.Print(sa.Condition), ap.Print(sa.Message))
+}
+
+func (ap *AstPrinter) visitPragmaExpression(pe *PragmaExpression) string {
+	pragmas := []string{}
+	for _, pragma := range pe.Pragmas {
+		pragmas = append(pragmas, ap.Print(pragma))
+	}
+	return fmt.Sprintf("PragmaExpression(%s)", strings.Join(pragmas, ", "))
+}
+
 func (ap *AstPrinter) visitIntegerLiteral(il *IntegerLiteral) string {
 	return fmt.Sprintf("IntegerLiteral(%d)", il.Value)
 }
@@ -329,31 +478,27 @@ func (ap *AstPrinter) visitIncludeDirective(id *IncludeDirective) string {
 }
 
 func (ap *AstPrinter) visitDefineDirective(dd *DefineDirective) string {
-	return fmt.Sprintf("DefineDirective(Name: %s, Value: %s)", dd.Name, ap.Print(dd.Value))
+	if value, ok := dd.ConstantValue(); ok {
+		return fmt.Sprintf("DefineDirective(Pattern: %s, Substitution: %s, Value: %d)", dd.Pattern, dd.Substitution, value)
+	}
+	return fmt.Sprintf("DefineDirective(Pattern: %s, Substitution: %s)", dd.Pattern, dd.Substitution)
 }
 
-func (ap *AstPrinter) visitIfDefDirective(idd *IfDefDirective) string {
+func (ap *AstPrinter) visitConditionalDirective(cd *ConditionalDirective) string {
 	var out strings.Builder
-	out.WriteString("IfDefDirective\n")
+	out.WriteString("ConditionalDirective\n")
 	ap.indentLevel++
-	out.WriteString(ap.indent())
-	out.WriteString("Condition: ")
-	out.WriteString(idd.Condition)
-	out.WriteString("\n")
-	out.WriteString(ap.indent())
-	out.WriteString("Body:\n")
-	ap.indentLevel++
-	for _, stmt := range idd.Body {
+	for _, branch := range cd.Branches {
 		out.WriteString(ap.indent())
-		out.WriteString(ap.Print(stmt))
+		out.WriteString("Branch: ")
+		out.WriteString(branch.Token.Literal)
+		if branch.Condition != "" {
+			out.WriteString(" ")
+			out.WriteString(branch.Condition)
+		}
 		out.WriteString("\n")
-	}
-	ap.indentLevel--
-	if idd.ElseBody != nil {
-		out.WriteString(ap.indent())
-		out.WriteString("ElseBody:\n")
 		ap.indentLevel++
-		for _, stmt := range idd.ElseBody {
+		for _, stmt := range branch.Body {
 			out.WriteString(ap.indent())
 			out.WriteString(ap.Print(stmt))
 			out.WriteString("\n")
@@ -364,6 +509,55 @@ func (ap *AstPrinter) visitIfDefDirective(idd *IfDefDirective) string {
 	return out.String()
 }
 
+func (ap *AstPrinter) visitEmitDirective(ed *EmitDirective) string {
+	return fmt.Sprintf("EmitDirective(%s)", ap.printEmitInstruction(ed.Instruction))
+}
+
+func (ap *AstPrinter) printEmitInstruction(ei *EmitInstruction) string {
+	kinds := map[EmitOperandKind]string{EmitNumber: "Number", EmitSymbol: "Symbol", EmitLabel: "Label"}
+	operands := []string{}
+	for _, op := range ei.Operands {
+		operands = append(operands, fmt.Sprintf("%s(%s)", kinds[op.Kind], op.Value))
+	}
+	return fmt.Sprintf("Opcode: %s, Operands: [%s]", ei.Opcode, strings.Join(operands, ", "))
+}
+
+func (ap *AstPrinter) visitEndInputDirective(eid *EndInputDirective) string {
+	return fmt.Sprintf("EndInputDirective(%s)", eid.Token.Literal)
+}
+
+func (ap *AstPrinter) visitUndefDirective(ud *UndefDirective) string {
+	return fmt.Sprintf("UndefDirective(%s)", ud.Name)
+}
+
+func (ap *AstPrinter) visitErrorDirective(ed *ErrorDirective) string {
+	return fmt.Sprintf("ErrorDirective(%s)", ed.Message)
+}
+
+func (ap *AstPrinter) visitWarningDirective(wd *WarningDirective) string {
+	return fmt.Sprintf("WarningDirective(%s)", wd.Message)
+}
+
+func (ap *AstPrinter) visitAssertDirective(ad *AssertDirective) string {
+	return fmt.Sprintf("AssertDirective(%s)", ad.Condition)
+}
+
+func (ap *AstPrinter) visitLineDirective(ld *LineDirective) string {
+	return fmt.Sprintf("LineDirective(%d)", ld.Line)
+}
+
+func (ap *AstPrinter) visitFileDirective(fd *FileDirective) string {
+	return fmt.Sprintf("FileDirective(%s)", fd.Name)
+}
+
+func (ap *AstPrinter) visitSectionDirective(sd *SectionDirective) string {
+	return fmt.Sprintf("SectionDirective(%s)", sd.Name)
+}
+
+func (ap *AstPrinter) visitPragmaDirective(pd *PragmaDirective) string {
+	return fmt.Sprintf("PragmaDirective(Name: %s, Arguments: %s)", pd.Name, pd.Arguments)
+}
+
 func (ap *AstPrinter) visitNativeFunctionDeclaration(nfd *NativeFunctionDeclaration) string {
 	var out strings.Builder
 	out.WriteString("NativeFunctionDeclaration\n")
@@ -389,17 +583,25 @@ func (ap *AstPrinter) visitNativeFunctionDeclaration(nfd *NativeFunctionDeclarat
 	ap.indentLevel--
 	return out.String()
 }
-func (ap *AstPrinter) visitStateDeclaration(sd *StateDeclaration) string {
+func (ap *AstPrinter) visitStateStatement(ss *StateStatement) string {
 	var out strings.Builder
-	out.WriteString("StateDeclaration\n")
+	out.WriteString("StateStatement\n")
 	ap.indentLevel++
+	if ss.Condition != nil {
+		out.WriteString(ap.indent())
+		out.WriteString("Condition: ")
+		out.WriteString(ap.Print(ss.Condition))
+		out.WriteString("\n")
+	}
+	if ss.Automaton != "" {
+		out.WriteString(ap.indent())
+		out.WriteString("Automaton: ")
+		out.WriteString(ss.Automaton)
+		out.WriteString("\n")
+	}
 	out.WriteString(ap.indent())
-	out.WriteString("Name: ")
-	out.WriteString(ap.Print(sd.Name))
-	out.WriteString("\n")
-	out.WriteString(ap.indent())
-	out.WriteString("Body: ")
-	out.WriteString(ap.Print(sd.Body))
+	out.WriteString("State: ")
+	out.WriteString(ap.Print(ss.State))
 	ap.indentLevel--
 	return out.String()
 }
@@ -420,12 +622,212 @@ func (ap *AstPrinter) visitFunctionDeclaration(fd *FunctionDeclaration) string {
 		out.WriteString(ap.Print(param))
 	}
 	out.WriteString("\n")
+	if fd.State != nil {
+		out.WriteString(ap.indent())
+		out.WriteString("State: ")
+		out.WriteString(fd.State.String())
+		out.WriteString("\n")
+	}
 	out.WriteString(ap.indent())
 	out.WriteString("Body: ")
 	out.WriteString(ap.Print(fd.Body))
 	ap.indentLevel--
 	return out.String()
 }
+
+func (ap *AstPrinter) visitHookDeclaration(hd *HookDeclaration) string {
+	var out strings.Builder
+	out.WriteString("HookDeclaration\n")
+	ap.indentLevel++
+	out.WriteString(ap.indent())
+	out.WriteString("Name: ")
+	out.WriteString(ap.Print(hd.Name))
+	if hd.Function {
+		out.WriteString(" (function)")
+	}
+	out.WriteString("\n")
+	out.WriteString(ap.indent())
+	out.WriteString("Parameters: ")
+	for i, param := range hd.Parameters {
+		if i > 0 {
+			out.WriteString(", ")
+		}
+		out.WriteString(ap.Print(param))
+	}
+	out.WriteString("\n")
+	out.WriteString(ap.indent())
+	out.WriteString("Body: ")
+	out.WriteString(ap.Print(hd.Body))
+	ap.indentLevel--
+	return out.String()
+}
+
+func (ap *AstPrinter) visitForeachStatement(fs *ForeachStatement) string {
+	var out strings.Builder
+	out.WriteString("ForeachStatement\n")
+	ap.indentLevel++
+	out.WriteString(ap.indent())
+	out.WriteString("Variable: ")
+	out.WriteString(ap.Print(fs.Variable))
+	if fs.Declared {
+		out.WriteString(" (new)")
+	}
+	out.WriteString("\n")
+	out.WriteString(ap.indent())
+	out.WriteString("Iterator: ")
+	out.WriteString(ap.Print(fs.Iterator))
+	out.WriteString("\n")
+	out.WriteString(ap.indent())
+	out.WriteString("Body: ")
+	out.WriteString(ap.Print(fs.Body))
+	ap.indentLevel--
+	return out.String()
+}
+
+func (ap *AstPrinter) visitIteratorDeclaration(id *IteratorDeclaration) string {
+	if id.Count != nil {
+		return fmt.Sprintf("IteratorDeclaration(Name: %s, Count: %s, Size: %s)", id.Name.Value, ap.Print(id.Count), ap.Print(id.Size))
+	}
+	return fmt.Sprintf("IteratorDeclaration(Name: %s, Size: %s)", id.Name.Value, ap.Print(id.Size))
+}
+
+func (ap *AstPrinter) visitTimerDeclaration(td *TimerDeclaration) string {
+	var out strings.Builder
+	out.WriteString("TimerDeclaration(" + td.TokenLiteral() + ")\n")
+	ap.indentLevel++
+	out.WriteString(ap.indent())
+	out.WriteString("Name: ")
+	out.WriteString(ap.Print(td.Name))
+	out.WriteString("\n")
+	out.WriteString(ap.indent())
+	out.WriteString("Interval: ")
+	out.WriteString(ap.Print(td.Interval))
+	out.WriteString("\n")
+	out.WriteString(ap.indent())
+	out.WriteString("Parameters: ")
+	for i, param := range td.Parameters {
+		if i > 0 {
+			out.WriteString(", ")
+		}
+		out.WriteString(ap.Print(param))
+	}
+	out.WriteString("\n")
+	out.WriteString(ap.indent())
+	out.WriteString("Body: ")
+	out.WriteString(ap.Print(td.Body))
+	ap.indentLevel--
+	return out.String()
+}
+
+func (ap *AstPrinter) visitTimerCallExpression(tc *TimerCallExpression) string {
+	args := []string{}
+	for _, arg := range tc.Arguments {
+		args = append(args, ap.Print(arg))
+	}
+	if tc.Delay != nil {
+		return fmt.Sprintf("TimerCallExpression(%s %s, Delay: %s, Arguments: [%s])", tc.TokenLiteral(), tc.Timer.Value, ap.Print(tc.Delay), strings.Join(args, ", "))
+	}
+	return fmt.Sprintf("TimerCallExpression(%s %s, Arguments: [%s])", tc.TokenLiteral(), tc.Timer.Value, strings.Join(args, ", "))
+}
+
+func (ap *AstPrinter) visitStopExpression(se *StopExpression) string {
+	return fmt.Sprintf("StopExpression(%s)", ap.Print(se.Timer))
+}
+
+func (ap *AstPrinter) visitInlineDeclaration(id *InlineDeclaration) string {
+	var out strings.Builder
+	out.WriteString("InlineDeclaration\n")
+	ap.indentLevel++
+	out.WriteString(ap.indent())
+	out.WriteString("Name: ")
+	out.WriteString(ap.Print(id.Name))
+	out.WriteString("\n")
+	out.WriteString(ap.indent())
+	out.WriteString("Parameters: ")
+	for i, param := range id.Parameters {
+		if i > 0 {
+			out.WriteString(", ")
+		}
+		out.WriteString(ap.Print(param))
+	}
+	out.WriteString("\n")
+	out.WriteString(ap.indent())
+	out.WriteString("Body: ")
+	out.WriteString(ap.Print(id.Body))
+	ap.indentLevel--
+	return out.String()
+}
+
+func (ap *AstPrinter) visitUsingExpression(ue *UsingExpression) string {
+	return fmt.Sprintf("UsingExpression(%s %s)", ue.Kind, ue.Name.Value)
+}
+
+func (ap *AstPrinter) visitRemoteFunctionDeclaration(rd *RemoteFunctionDeclaration) string {
+	if rd.Body == nil {
+		return fmt.Sprintf("RemoteFunctionDeclaration(%s %s)", rd.TokenLiteral(), rd.Signature())
+	}
+	var out strings.Builder
+	out.WriteString(fmt.Sprintf("RemoteFunctionDeclaration(%s %s)\n", rd.TokenLiteral(), rd.Signature()))
+	ap.indentLevel++
+	out.WriteString(ap.indent())
+	out.WriteString("Body: ")
+	out.WriteString(ap.Print(rd.Body))
+	ap.indentLevel--
+	return out.String()
+}
+
+func (ap *AstPrinter) visitLoadTextDeclaration(ld *LoadTextDeclaration) string {
+	sections := []string{}
+	for _, section := range ld.Sections {
+		sections = append(sections, section.File+"["+section.Section+"]")
+	}
+	return fmt.Sprintf("LoadTextDeclaration(%s)", strings.Join(sections, ", "))
+}
+
+func (ap *AstPrinter) visitTextIdentifier(ti *TextIdentifier) string {
+	return fmt.Sprintf("TextIdentifier(%s)", ti.Name)
+}
+
+func (ap *AstPrinter) visitYieldStatement(ys *YieldStatement) string {
+	if ys.Value == nil {
+		return "YieldStatement"
+	}
+	return fmt.Sprintf("YieldStatement(Value: %s)", ap.Print(ys.Value))
+}
+
+func (ap *AstPrinter) visitInlineReturnStatement(ir *InlineReturnStatement) string {
+	if ir.Value == nil {
+		return "InlineReturnStatement"
+	}
+	return fmt.Sprintf("InlineReturnStatement(Value: %s)", ap.Print(ir.Value))
+}
+
+func (ap *AstPrinter) visitSpecialIdentifier(si *SpecialIdentifier) string {
+	return fmt.Sprintf("SpecialIdentifier(%s)", si.Name)
+}
+
+func (ap *AstPrinter) visitVarArgsExpression(va *VarArgsExpression) string {
+	if va.Start == nil {
+		return "VarArgsExpression"
+	}
+	return fmt.Sprintf("VarArgsExpression(Start: %s)", ap.Print(va.Start))
+}
+
+func (ap *AstPrinter) visitCommandDeclaration(cd *CommandDeclaration) string {
+	var out strings.Builder
+	out.WriteString("CommandDeclaration\n")
+	ap.indentLevel++
+	out.WriteString(ap.indent())
+	out.WriteString("Command: ")
+	out.WriteString(cd.Processor + " " + cd.Command)
+	out.WriteString("\n")
+	out.WriteString(ap.indent())
+	out.WriteString("Function: ")
+	out.WriteString(ap.Print(cd.FunctionDeclaration))
+	ap.indentLevel--
+	return out.String()
+}
+
 func (ap *AstPrinter) indent() string {
 	return strings.Repeat("  ", ap.indentLevel)
 }
@@ -48,4 +48,5 @@ type Identifier struct {
 
 func (i *Identifier) expressionNode()      {}
 func (i *Identifier) TokenLiteral() string { return i.Token.Literal }
+func (i *Identifier) Pos() token.Token     { return i.Token }
 func (i *Identifier) String() string       { return i.Value }
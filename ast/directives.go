@@ -5,25 +5,172 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/Tramposo1312/pawn-parser/constexpr"
 	"github.com/Tramposo1312/pawn-parser/token"
 )
 
 type IncludeDirective struct {
-	Token token.Token //  '#include'
-	Path  string
+	Token    token.Token //  '#include' or '#tryinclude'
+	Path     string
+	System   bool // written <name>: only the include directories are searched
+	Optional bool // #tryinclude: a missing file is not an error
 }
 
+// DefineDirective is a #define. As in pawncc, the substitution is text to
+// be pasted wherever the pattern matches, not an expression, so it is kept
+// as the tokens written; ConstantValue gives its value when it happens to
+// be a constant expression.
 type DefineDirective struct {
-	Token token.Token //  '#define'
+	Token        token.Token //  '#define'
+	Name         string      // the name the pattern starts with, e.g. "CMD"
+	Pattern      string      // the whole pattern, e.g. "CMD:%0(%1)"
+	Substitution string
+	Body         []token.Token
+
+	constant  int64
+	evaluated bool
+	isConst   bool
+}
+
+// ConstantValue returns the value of the substitution when it is a
+// constant expression on its own, such as the 500 of
+// "#define MAX_PLAYERS 500". It is computed on first use. A pattern macro
+// such as "#define X(%0) 5" is never a constant, as it only replaces text
+// that matches its pattern.
+func (dd *DefineDirective) ConstantValue() (int64, bool) {
+	if !dd.evaluated && len(dd.Body) > 0 && dd.Pattern == dd.Name {
+		value, err := constexpr.Eval(dd.Body, nil)
+		dd.constant, dd.isConst = value, err == nil
+	}
+	dd.evaluated = true
+	return dd.constant, dd.isConst
+}
+
+// ConditionalDirective is an #if, #ifdef or #ifndef block with every one
+// of its branches, as written. Parsing the raw source keeps all branches
+// for tooling; a preprocessor evaluates the conditions instead and only
+// passes on the active branch, so this node never appears in that view.
+type ConditionalDirective struct {
+	Token    token.Token // the opening '#if', '#ifdef' or '#ifndef'
+	Branches []*ConditionalBranch
+	End      token.Token // '#endif'
+}
+
+// ConditionalBranch is one arm of a ConditionalDirective. Condition is the
+// text after the directive, empty for #else; ConditionTokens keeps its
+// tokens so that it can be evaluated with the constexpr package.
+type ConditionalBranch struct {
+	Token           token.Token // '#if', '#ifdef', '#ifndef', '#elseif' or '#else'
+	Condition       string
+	ConditionTokens []token.Token
+	Body            []Statement
+}
+
+// EmitOperandKind says how an inline assembly operand is interpreted.
+type EmitOperandKind int
+
+const (
+	EmitNumber EmitOperandKind = iota // a literal cell value
+	EmitSymbol                        // a variable, constant or function name
+	EmitLabel                         // a jump target
+)
+
+type EmitOperand struct {
+	Token token.Token
+	Kind  EmitOperandKind
+	Value string
+}
+
+// EmitInstruction is a single AMX instruction such as "load.s.pri 12".
+type EmitInstruction struct {
+	Token    token.Token // the first token of the opcode
+	Opcode   string      // lower case, e.g. "load.s.pri"
+	Operands []*EmitOperand
+}
+
+// EmitDirective is an "#emit" line of inline assembly.
+type EmitDirective struct {
+	Token       token.Token //  '#emit'
+	Instruction *EmitInstruction
+}
+
+// PragmaDirective is a "#pragma name arguments" line. Arguments always
+// holds the raw text after the name; for the well known pragmas the
+// arguments are also decoded into the typed fields:
+//
+//	unused a, b          Symbols
+//	deprecated text      Message
+//	dynamic/tabsize/semicolon/ctrlchar N   Value
+//	warning push|pop|enable|disable N, ...  Action, Warnings
+//	rational Tag(N)      Symbols, Value
+//	option -d3 -O1       Options
+//
+// A numeric argument is a constant expression; one naming constants of
+// the script cannot be evaluated and leaves Value zero.
+type PragmaDirective struct {
+	Token     token.Token //  '#pragma'
+	Name      string
+	Arguments string
+	Symbols   []string
+	Message   string
+	Value     int64
+	Action    string
+	Warnings  []int64
+	Options   []string
+}
+
+// EndInputDirective is "#endinput" (or its alias "#endscript"), which makes
+// the compiler stop reading the current file. The parser keeps the
+// statements after it, as they may belong to another #if branch.
+type EndInputDirective struct {
+	Token token.Token // '#endinput'
+}
+
+type UndefDirective struct {
+	Token token.Token // '#undef'
+	Name  string
+}
+
+// ErrorDirective is "#error message", which stops compilation with a user
+// error. Message is the rest of the line as written.
+type ErrorDirective struct {
+	Token   token.Token // '#error'
+	Message string
+}
+
+// WarningDirective is "#warning message", a user warning.
+type WarningDirective struct {
+	Token   token.Token // '#warning'
+	Message string
+}
+
+// AssertDirective is "#assert expression": compilation fails if the
+// constant expression is zero. Like ConditionalBranch it keeps the
+// expression's tokens for the constexpr package.
+type AssertDirective struct {
+	Token           token.Token // '#assert'
+	Condition       string
+	ConditionTokens []token.Token
+}
+
+// LineDirective is "#line number", which renumbers its own line.
+type LineDirective struct {
+	Token token.Token // '#line'
+	Line  int64
+}
+
+// FileDirective is "#file name", which renames the current file in
+// diagnostics.
+type FileDirective struct {
+	Token token.Token // '#file'
 	Name  string
-	Value Expression
 }
 
-type IfDefDirective struct {
-	Token     token.Token //  '#ifdef'
-	Condition string
-	Body      []Statement
-	ElseBody  []Statement
+// SectionDirective is "#section name", which starts a new section of the
+// file for static declarations.
+type SectionDirective struct {
+	Token token.Token // '#section'
+	Name  string
 }
 
 type NativeFunctionDeclaration struct {
@@ -33,53 +180,129 @@ type NativeFunctionDeclaration struct {
 	ReturnType Expression
 }
 
-type StateDeclaration struct {
-	Token token.Token //  'state'
-	Name  *Identifier
-	Body  *BlockStatement
+// StateStatement is a state transition: "state (condition) automaton:name;".
+type StateStatement struct {
+	Token     token.Token //  'state'
+	Condition Expression
+	Automaton string
+	State     *Identifier
 }
+
+// StateSelector is the "<automaton:state1, state2>" suffix of a state
+// function. An empty state list ("<>" or "<automaton:>") marks the fallback
+// function for the automaton.
+type StateSelector struct {
+	Token     token.Token //  '<'
+	Automaton string
+	States    []*Identifier
+}
+
 type FunctionDeclaration struct {
 	Token      token.Token
 	Name       *Identifier
 	Parameters []*Identifier
+	State      *StateSelector
 	Body       *BlockStatement
 }
 
 // ==== String()
 
 func (id *IncludeDirective) String() string {
-	return "#include " + id.Path
+	if id.System {
+		return id.Token.Literal + " <" + id.Path + ">"
+	}
+	return id.Token.Literal + ` "` + id.Path + `"`
 }
 
 func (dd *DefineDirective) String() string {
 	var out bytes.Buffer
 	out.WriteString("#define ")
-	out.WriteString(dd.Name)
-	out.WriteString(" ")
-	out.WriteString(dd.Value.String())
+	out.WriteString(dd.Pattern)
+	if dd.Substitution != "" {
+		out.WriteString(" ")
+		out.WriteString(dd.Substitution)
+	}
 	return out.String()
 }
 
-func (idd *IfDefDirective) String() string {
+func (cd *ConditionalDirective) String() string {
 	var out bytes.Buffer
-	out.WriteString("#ifdef ")
-	out.WriteString(idd.Condition)
-	out.WriteString(" {\n")
-	for _, s := range idd.Body {
-		out.WriteString(s.String())
+	for _, branch := range cd.Branches {
+		out.WriteString(branch.Token.Literal)
+		if branch.Condition != "" {
+			out.WriteString(" ")
+			out.WriteString(branch.Condition)
+		}
 		out.WriteString("\n")
-	}
-	if idd.ElseBody != nil {
-		out.WriteString("} else {\n")
-		for _, s := range idd.ElseBody {
+		for _, s := range branch.Body {
 			out.WriteString(s.String())
 			out.WriteString("\n")
 		}
 	}
-	out.WriteString("}")
+	out.WriteString("#endif")
+	return out.String()
+}
+
+func (eo *EmitOperand) String() string {
+	return eo.Value
+}
+
+func (ei *EmitInstruction) String() string {
+	var out bytes.Buffer
+	out.WriteString(ei.Opcode)
+	for _, op := range ei.Operands {
+		out.WriteString(" ")
+		out.WriteString(op.String())
+	}
 	return out.String()
 }
 
+func (ed *EmitDirective) String() string {
+	return "#emit " + ed.Instruction.String()
+}
+
+func (pd *PragmaDirective) String() string {
+	if pd.Arguments == "" {
+		return "#pragma " + pd.Name
+	}
+	return "#pragma " + pd.Name + " " + pd.Arguments
+}
+
+func (eid *EndInputDirective) String() string {
+	return eid.Token.Literal
+}
+
+func (ud *UndefDirective) String() string {
+	return "#undef " + ud.Name
+}
+
+func (ed *ErrorDirective) String() string {
+	return "#error " + ed.Message
+}
+
+func (wd *WarningDirective) String() string {
+	return "#warning " + wd.Message
+}
+
+func (ad *AssertDirective) String() string {
+	return "#assert " + ad.Condition
+}
+
+func (ld *LineDirective) String() string {
+	return fmt.Sprintf("#line %d", ld.Line)
+}
+
+func (fd *FileDirective) String() string {
+	return fmt.Sprintf("#file %q", fd.Name)
+}
+
+func (sd *SectionDirective) String() string {
+	if sd.Name == "" {
+		return "#section"
+	}
+	return "#section " + sd.Name
+}
+
 func (nfd *NativeFunctionDeclaration) String() string {
 	var out bytes.Buffer
 	params := []string{}
@@ -99,39 +322,136 @@ func (nfd *NativeFunctionDeclaration) String() string {
 	return out.String()
 }
 
-func (sd *StateDeclaration) String() string {
-	return fmt.Sprintf("state %s %s", sd.Name.String(), sd.Body.String())
+func (ss *StateStatement) String() string {
+	var out bytes.Buffer
+	out.WriteString("state ")
+	if ss.Condition != nil {
+		out.WriteString(parenthesized(ss.Condition))
+		out.WriteString(" ")
+	}
+	if ss.Automaton != "" {
+		out.WriteString(ss.Automaton + ":")
+	}
+	out.WriteString(ss.State.String())
+	out.WriteString(";")
+	return out.String()
+}
+
+func (ss *StateSelector) String() string {
+	states := []string{}
+	for _, s := range ss.States {
+		states = append(states, s.String())
+	}
+	if ss.Automaton != "" {
+		return fmt.Sprintf("<%s:%s>", ss.Automaton, strings.Join(states, ", "))
+	}
+	return fmt.Sprintf("<%s>", strings.Join(states, ", "))
 }
+
+// parenthesized returns exp's text in parentheses. Infix, prefix, postfix
+// and index expressions already print their own, so they are not wrapped
+// twice.
+func parenthesized(exp Expression) string {
+	switch exp.(type) {
+	case *InfixExpression, *PrefixExpression, *PostfixExpression, *IndexExpression:
+		return exp.String()
+	}
+	return "(" + exp.String() + ")"
+}
+
+// IsFallback reports whether the selector names no states, making the
+// function the fallback for its automaton.
+func (ss *StateSelector) IsFallback() bool {
+	return len(ss.States) == 0
+}
+
 func (fd *FunctionDeclaration) String() string {
 	var out bytes.Buffer
 	params := []string{}
 	for _, p := range fd.Parameters {
 		params = append(params, p.String())
 	}
-	out.WriteString(fd.TokenLiteral() + " ")
+	if fd.Token.Type != token.IDENT {
+		out.WriteString(fd.TokenLiteral() + " ")
+	}
 	out.WriteString(fd.Name.String())
 	out.WriteString("(")
 	out.WriteString(strings.Join(params, ", "))
 	out.WriteString(") ")
+	if fd.State != nil {
+		out.WriteString(fd.State.String() + " ")
+	}
 	out.WriteString(fd.Body.String())
 	return out.String()
 }
 
+// IsEntry reports whether the function is an automaton "entry" function,
+// which runs when its state is entered.
+func (fd *FunctionDeclaration) IsEntry() bool {
+	return fd.Name.Value == "entry"
+}
+
 // ====
 func (id *IncludeDirective) statementNode()       {}
 func (id *IncludeDirective) TokenLiteral() string { return id.Token.Literal }
+func (id *IncludeDirective) Pos() token.Token     { return id.Token }
 
 func (dd *DefineDirective) statementNode()       {}
 func (dd *DefineDirective) TokenLiteral() string { return dd.Token.Literal }
+func (dd *DefineDirective) Pos() token.Token     { return dd.Token }
+
+func (cd *ConditionalDirective) statementNode()       {}
+func (cd *ConditionalDirective) TokenLiteral() string { return cd.Token.Literal }
+func (cd *ConditionalDirective) Pos() token.Token     { return cd.Token }
+
+func (ed *EmitDirective) statementNode()       {}
+func (ed *EmitDirective) TokenLiteral() string { return ed.Token.Literal }
+func (ed *EmitDirective) Pos() token.Token     { return ed.Token }
+
+func (pd *PragmaDirective) statementNode()       {}
+func (pd *PragmaDirective) TokenLiteral() string { return pd.Token.Literal }
+func (pd *PragmaDirective) Pos() token.Token     { return pd.Token }
+
+func (eid *EndInputDirective) statementNode()       {}
+func (eid *EndInputDirective) TokenLiteral() string { return eid.Token.Literal }
+func (eid *EndInputDirective) Pos() token.Token     { return eid.Token }
+
+func (ud *UndefDirective) statementNode()       {}
+func (ud *UndefDirective) TokenLiteral() string { return ud.Token.Literal }
+func (ud *UndefDirective) Pos() token.Token     { return ud.Token }
+
+func (ed *ErrorDirective) statementNode()       {}
+func (ed *ErrorDirective) TokenLiteral() string { return ed.Token.Literal }
+func (ed *ErrorDirective) Pos() token.Token     { return ed.Token }
+
+func (wd *WarningDirective) statementNode()       {}
+func (wd *WarningDirective) TokenLiteral() string { return wd.Token.Literal }
+func (wd *WarningDirective) Pos() token.Token     { return wd.Token }
+
+func (ad *AssertDirective) statementNode()       {}
+func (ad *AssertDirective) TokenLiteral() string { return ad.Token.Literal }
+func (ad *AssertDirective) Pos() token.Token     { return ad.Token }
+
+func (ld *LineDirective) statementNode()       {}
+func (ld *LineDirective) TokenLiteral() string { return ld.Token.Literal }
+func (ld *LineDirective) Pos() token.Token     { return ld.Token }
+
+func (fd *FileDirective) statementNode()       {}
+func (fd *FileDirective) TokenLiteral() string { return fd.Token.Literal }
+func (fd *FileDirective) Pos() token.Token     { return fd.Token }
 
-func (idd *IfDefDirective) statementNode()       {}
-func (idd *IfDefDirective) TokenLiteral() string { return idd.Token.Literal }
+func (sd *SectionDirective) statementNode()       {}
+func (sd *SectionDirective) TokenLiteral() string { return sd.Token.Literal }
+func (sd *SectionDirective) Pos() token.Token     { return sd.Token }
 
 func (nfd *NativeFunctionDeclaration) statementNode()       {}
 func (nfd *NativeFunctionDeclaration) TokenLiteral() string { return nfd.Token.Literal }
+func (nfd *NativeFunctionDeclaration) Pos() token.Token     { return nfd.Token }
 
-func (sd *StateDeclaration) statementNode()       {}
-func (sd *StateDeclaration) TokenLiteral() string { return sd.Token.Literal }
+func (ss *StateStatement) statementNode()       {}
+func (ss *StateStatement) TokenLiteral() string { return ss.Token.Literal }
+func (ss *StateStatement) Pos() token.Token     { return ss.Token }
 
 func (fd *FunctionDeclaration) statementNode()       {}
 func (fd *FunctionDeclaration) TokenLiteral() string { return fd.Token.Literal }
+func (fd *FunctionDeclaration) Pos() token.Token     { return fd.Token }
@@ -19,6 +19,7 @@ type PrefixExpression struct {
 
 func (pe *PrefixExpression) expressionNode()      {}
 func (pe *PrefixExpression) TokenLiteral() string { return pe.Token.Literal }
+func (pe *PrefixExpression) Pos() token.Token     { return pe.Token }
 func (pe *PrefixExpression) String() string {
 	var out bytes.Buffer
 	out.WriteString("(")
@@ -37,6 +38,7 @@ type InfixExpression struct {
 
 func (ie *InfixExpression) expressionNode()      {}
 func (ie *InfixExpression) TokenLiteral() string { return ie.Token.Literal }
+func (ie *InfixExpression) Pos() token.Token     { return ie.Token }
 func (ie *InfixExpression) String() string {
 	var out bytes.Buffer
 
@@ -49,6 +51,43 @@ func (ie *InfixExpression) String() string {
 	return out.String()
 }
 
+// PostfixExpression is an increment or decrement after its operand, e.g. i++.
+type PostfixExpression struct {
+	Token    token.Token // The postfix token, e.g. ++
+	Left     Expression
+	Operator string
+}
+
+func (pe *PostfixExpression) expressionNode()      {}
+func (pe *PostfixExpression) TokenLiteral() string { return pe.Token.Literal }
+func (pe *PostfixExpression) Pos() token.Token     { return pe.Token }
+func (pe *PostfixExpression) String() string {
+	var out bytes.Buffer
+	out.WriteString("(")
+	out.WriteString(pe.Left.String())
+	out.WriteString(pe.Operator)
+	out.WriteString(")")
+	return out.String()
+}
+
+// CommaExpression evaluates a sequence of expressions left to right, as in
+// the clauses of "for (i = 0, j = 10; i < j; i++, j--)".
+type CommaExpression struct {
+	Token       token.Token // The first ',' token
+	Expressions []Expression
+}
+
+func (ce *CommaExpression) expressionNode()      {}
+func (ce *CommaExpression) TokenLiteral() string { return ce.Token.Literal }
+func (ce *CommaExpression) Pos() token.Token     { return ce.Token }
+func (ce *CommaExpression) String() string {
+	exprs := []string{}
+	for _, e := range ce.Expressions {
+		exprs = append(exprs, e.String())
+	}
+	return "(" + strings.Join(exprs, ", ") + ")"
+}
+
 type CallExpression struct {
 	Token     token.Token // The '(' token
 	Function  Expression  // Identifier or FunctionLiteral
@@ -57,6 +96,7 @@ type CallExpression struct {
 
 func (ce *CallExpression) expressionNode()      {}
 func (ce *CallExpression) TokenLiteral() string { return ce.Token.Literal }
+func (ce *CallExpression) Pos() token.Token     { return ce.Token }
 func (ce *CallExpression) String() string {
 	var out bytes.Buffer
 	args := []string{}
@@ -78,6 +118,7 @@ type IndexExpression struct {
 
 func (ie *IndexExpression) expressionNode()      {}
 func (ie *IndexExpression) TokenLiteral() string { return ie.Token.Literal }
+func (ie *IndexExpression) Pos() token.Token     { return ie.Token }
 func (ie *IndexExpression) String() string {
 	var out bytes.Buffer
 	out.WriteString("(")
@@ -87,3 +128,79 @@ func (ie *IndexExpression) String() string {
 	out.WriteString("])")
 	return out.String()
 }
+
+// EmitExpression is the open.mp "__emit(...)" inline assembly expression,
+// holding one or more comma separated instructions.
+type EmitExpression struct {
+	Token        token.Token // The '__emit' token
+	Instructions []*EmitInstruction
+}
+
+func (ee *EmitExpression) expressionNode()      {}
+func (ee *EmitExpression) TokenLiteral() string { return ee.Token.Literal }
+func (ee *EmitExpression) Pos() token.Token     { return ee.Token }
+func (ee *EmitExpression) String() string {
+	instructions := []string{}
+	for _, in := range ee.Instructions {
+		instructions = append(instructions, in.String())
+	}
+	return "__emit(" + strings.Join(instructions, ", ") + ")"
+}
+
+// SymbolOperatorExpression is the open.mp "__nameof(Symbol)" or
+// "__addressof(Function)" operator, which gives the symbol's name as a
+// string or the function's address in the code section.
+type SymbolOperatorExpression struct {
+	Token  token.Token // The '__nameof' or '__addressof' token
+	Symbol *Identifier
+}
+
+func (so *SymbolOperatorExpression) expressionNode()      {}
+func (so *SymbolOperatorExpression) TokenLiteral() string { return so.Token.Literal }
+func (so *SymbolOperatorExpression) Pos() token.Token     { return so.Token }
+func (so *SymbolOperatorExpression) String() string {
+	return so.TokenLiteral() + "(" + so.Symbol.String() + ")"
+}
+
+// StaticAssertExpression is the open.mp "__static_assert(cond, "msg")",
+// a compile time assertion usable anywhere an expression is, or
+// "__static_check(cond)", which gives the result instead of failing.
+// Message is nil when no message is given.
+type StaticAssertExpression struct {
+	Token     token.Token // The '__static_assert' or '__static_check' token
+	Condition Expression
+	Message   Expression
+}
+
+func (sa *StaticAssertExpression) expressionNode()      {}
+func (sa *StaticAssertExpression) TokenLiteral() string { return sa.Token.Literal }
+func (sa *StaticAssertExpression) Pos() token.Token     { return sa.Token }
+func (sa *StaticAssertExpression) String() string {
+	if sa.Message == nil {
+		return sa.TokenLiteral() + "(" + sa.Condition.String() + ")"
+	}
+	return sa.TokenLiteral() + "(" + sa.Condition.String() + ", " + sa.Message.String() + ")"
+}
+
+// PragmaExpression is the open.mp "__pragma("naked", "unused x")"
+// operator, which applies a #pragma to the function or expression it is
+// in. Each string argument is one pragma, decoded as if by a #pragma line.
+type PragmaExpression struct {
+	Token   token.Token // The '__pragma' token
+	Pragmas []*PragmaDirective
+}
+
+func (pe *PragmaExpression) expressionNode()      {}
+func (pe *PragmaExpression) TokenLiteral() string { return pe.Token.Literal }
+func (pe *PragmaExpression) Pos() token.Token     { return pe.Token }
+func (pe *PragmaExpression) String() string {
+	pragmas := []string{}
+	for _, pragma := range pe.Pragmas {
+		text := pragma.Name
+		if pragma.Arguments != "" {
+			text += " " + pragma.Arguments
+		}
+		pragmas = append(pragmas, `"`+text+`"`)
+	}
+	return "__pragma(" + strings.Join(pragmas, ", ") + ")"
+}
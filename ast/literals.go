@@ -15,6 +15,7 @@ type IntegerLiteral struct {
 
 func (il *IntegerLiteral) expressionNode()      {}
 func (il *IntegerLiteral) TokenLiteral() string { return il.Token.Literal }
+func (il *IntegerLiteral) Pos() token.Token     { return il.Token }
 func (il *IntegerLiteral) String() string       { return il.Token.Literal }
 
 type FloatLiteral struct {
@@ -24,6 +25,7 @@ type FloatLiteral struct {
 
 func (fl *FloatLiteral) expressionNode()      {}
 func (fl *FloatLiteral) TokenLiteral() string { return fl.Token.Literal }
+func (fl *FloatLiteral) Pos() token.Token     { return fl.Token }
 func (fl *FloatLiteral) String() string       { return fl.Token.Literal }
 
 type StringLiteral struct {
@@ -33,6 +35,7 @@ type StringLiteral struct {
 
 func (sl *StringLiteral) expressionNode()      {}
 func (sl *StringLiteral) TokenLiteral() string { return sl.Token.Literal }
+func (sl *StringLiteral) Pos() token.Token     { return sl.Token }
 func (sl *StringLiteral) String() string       { return fmt.Sprintf("%q", sl.Value) }
 
 type BooleanLiteral struct {
@@ -42,6 +45,7 @@ type BooleanLiteral struct {
 
 func (bl *BooleanLiteral) expressionNode()      {}
 func (bl *BooleanLiteral) TokenLiteral() string { return bl.Token.Literal }
+func (bl *BooleanLiteral) Pos() token.Token     { return bl.Token }
 func (bl *BooleanLiteral) String() string       { return bl.Token.Literal }
 
 type NullLiteral struct {
@@ -50,6 +54,7 @@ type NullLiteral struct {
 
 func (nl *NullLiteral) expressionNode()      {}
 func (nl *NullLiteral) TokenLiteral() string { return nl.Token.Literal }
+func (nl *NullLiteral) Pos() token.Token     { return nl.Token }
 func (nl *NullLiteral) String() string       { return "null" }
 
 type ArrayLiteral struct {
@@ -59,6 +64,7 @@ type ArrayLiteral struct {
 
 func (al *ArrayLiteral) expressionNode()      {}
 func (al *ArrayLiteral) TokenLiteral() string { return al.Token.Literal }
+func (al *ArrayLiteral) Pos() token.Token     { return al.Token }
 func (al *ArrayLiteral) String() string {
 	elements := []string{}
 	for _, el := range al.Elements {
@@ -75,6 +81,7 @@ type FunctionLiteral struct {
 
 func (fl *FunctionLiteral) expressionNode()      {}
 func (fl *FunctionLiteral) TokenLiteral() string { return fl.Token.Literal }
+func (fl *FunctionLiteral) Pos() token.Token     { return fl.Token }
 func (fl *FunctionLiteral) String() string {
 	var out bytes.Buffer
 	params := []string{}
@@ -0,0 +1,32 @@
+package ast
+
+import (
+	"github.com/Tramposo1312/pawn-parser/token"
+)
+
+// Positioned is implemented by every node but Program: Pos returns the
+// token the node was built from, which gives its position in the source.
+type Positioned interface {
+	Pos() token.Token
+}
+
+// NodeToken returns the token a node was built from. It is false for
+// Program, which has no token of its own.
+func NodeToken(node Node) (token.Token, bool) {
+	n, ok := node.(Positioned)
+	if !ok {
+		return token.Token{}, false
+	}
+	return n.Pos(), true
+}
+
+// ExpansionOf returns the macro expansion a node came from, or nil when it
+// was written out in the source. The rest of the chain is reached through
+// the origin's Parent.
+func ExpansionOf(node Node) *token.Origin {
+	tok, ok := NodeToken(node)
+	if !ok {
+		return nil
+	}
+	return tok.Origin
+}
@@ -14,6 +14,7 @@ type LetStatement struct {
 
 func (ls *LetStatement) statementNode()       {}
 func (ls *LetStatement) TokenLiteral() string { return ls.Token.Literal }
+func (ls *LetStatement) Pos() token.Token     { return ls.Token }
 func (ls *LetStatement) String() string {
 	var out bytes.Buffer
 	out.WriteString(ls.TokenLiteral() + " ")
@@ -33,6 +34,7 @@ type ReturnStatement struct {
 
 func (rs *ReturnStatement) statementNode()       {}
 func (rs *ReturnStatement) TokenLiteral() string { return rs.Token.Literal }
+func (rs *ReturnStatement) Pos() token.Token     { return rs.Token }
 func (rs *ReturnStatement) String() string {
 	var out bytes.Buffer
 	out.WriteString(rs.TokenLiteral() + " ")
@@ -43,6 +45,61 @@ func (rs *ReturnStatement) String() string {
 	return out.String()
 }
 
+// SleepStatement suspends the script: "sleep;" or "sleep 100;".
+type SleepStatement struct {
+	Token token.Token // the 'sleep' token
+	Value Expression
+}
+
+func (ss *SleepStatement) statementNode()       {}
+func (ss *SleepStatement) TokenLiteral() string { return ss.Token.Literal }
+func (ss *SleepStatement) Pos() token.Token     { return ss.Token }
+func (ss *SleepStatement) String() string {
+	var out bytes.Buffer
+	out.WriteString(ss.TokenLiteral())
+	if ss.Value != nil {
+		out.WriteString(" " + ss.Value.String())
+	}
+	out.WriteString(";")
+	return out.String()
+}
+
+// ExitStatement aborts the script: "exit;" or "exit 1;".
+type ExitStatement struct {
+	Token token.Token // the 'exit' token
+	Value Expression
+}
+
+func (es *ExitStatement) statementNode()       {}
+func (es *ExitStatement) TokenLiteral() string { return es.Token.Literal }
+func (es *ExitStatement) Pos() token.Token     { return es.Token }
+func (es *ExitStatement) String() string {
+	var out bytes.Buffer
+	out.WriteString(es.TokenLiteral())
+	if es.Value != nil {
+		out.WriteString(" " + es.Value.String())
+	}
+	out.WriteString(";")
+	return out.String()
+}
+
+// AssertStatement aborts the script when its condition is false.
+type AssertStatement struct {
+	Token     token.Token // the 'assert' token
+	Condition Expression
+}
+
+func (as *AssertStatement) statementNode()       {}
+func (as *AssertStatement) TokenLiteral() string { return as.Token.Literal }
+func (as *AssertStatement) Pos() token.Token     { return as.Token }
+func (as *AssertStatement) String() string {
+	var out bytes.Buffer
+	out.WriteString(as.TokenLiteral() + " ")
+	out.WriteString(as.Condition.String())
+	out.WriteString(";")
+	return out.String()
+}
+
 type ExpressionStatement struct {
 	Token      token.Token // the first token of the expression
 	Expression Expression
@@ -50,6 +107,7 @@ type ExpressionStatement struct {
 
 func (es *ExpressionStatement) statementNode()       {}
 func (es *ExpressionStatement) TokenLiteral() string { return es.Token.Literal }
+func (es *ExpressionStatement) Pos() token.Token     { return es.Token }
 func (es *ExpressionStatement) String() string {
 	if es.Expression != nil {
 		return es.Expression.String()
@@ -57,6 +115,16 @@ func (es *ExpressionStatement) String() string {
 	return ""
 }
 
+// EmptyStatement is a lone ';', common after macro expansion.
+type EmptyStatement struct {
+	Token token.Token // the ';' token
+}
+
+func (es *EmptyStatement) statementNode()       {}
+func (es *EmptyStatement) TokenLiteral() string { return es.Token.Literal }
+func (es *EmptyStatement) Pos() token.Token     { return es.Token }
+func (es *EmptyStatement) String() string       { return ";" }
+
 type BlockStatement struct {
 	Token      token.Token // the { token
 	Statements []Statement
@@ -64,6 +132,7 @@ type BlockStatement struct {
 
 func (bs *BlockStatement) statementNode()       {}
 func (bs *BlockStatement) TokenLiteral() string { return bs.Token.Literal }
+func (bs *BlockStatement) Pos() token.Token     { return bs.Token }
 func (bs *BlockStatement) String() string {
 	var out bytes.Buffer
 	for _, s := range bs.Statements {
@@ -81,6 +150,7 @@ type IfStatement struct {
 
 func (is *IfStatement) statementNode()       {}
 func (is *IfStatement) TokenLiteral() string { return is.Token.Literal }
+func (is *IfStatement) Pos() token.Token     { return is.Token }
 func (is *IfStatement) String() string {
 	var out bytes.Buffer
 	out.WriteString("if (")
@@ -102,6 +172,7 @@ type WhileStatement struct {
 
 func (ws *WhileStatement) statementNode()       {}
 func (ws *WhileStatement) TokenLiteral() string { return ws.Token.Literal }
+func (ws *WhileStatement) Pos() token.Token     { return ws.Token }
 func (ws *WhileStatement) String() string {
 	var out bytes.Buffer
 	out.WriteString("while")
@@ -121,6 +192,7 @@ type ForStatement struct {
 
 func (fs *ForStatement) statementNode()       {}
 func (fs *ForStatement) TokenLiteral() string { return fs.Token.Literal }
+func (fs *ForStatement) Pos() token.Token     { return fs.Token }
 func (fs *ForStatement) String() string {
 	var out bytes.Buffer
 	out.WriteString("for (")
@@ -147,6 +219,7 @@ type TagDeclaration struct {
 
 func (td *TagDeclaration) statementNode()       {}
 func (td *TagDeclaration) TokenLiteral() string { return td.Token.Literal }
+func (td *TagDeclaration) Pos() token.Token     { return td.Token }
 func (td *TagDeclaration) String() string {
 	var out bytes.Buffer
 	out.WriteString("tag ")
@@ -162,6 +235,7 @@ type EnumDeclaration struct {
 
 func (ed *EnumDeclaration) statementNode()       {}
 func (ed *EnumDeclaration) TokenLiteral() string { return ed.Token.Literal }
+func (ed *EnumDeclaration) Pos() token.Token     { return ed.Token }
 func (ed *EnumDeclaration) String() string {
 	var out bytes.Buffer
 	out.WriteString("enum ")
@@ -16,6 +16,7 @@ type TypeName struct {
 
 func (tn *TypeName) expressionNode()      {}
 func (tn *TypeName) TokenLiteral() string { return tn.Token.Literal }
+func (tn *TypeName) Pos() token.Token     { return tn.Token }
 func (tn *TypeName) String() string       { return tn.Name }
 
 type ArrayType struct {
@@ -25,6 +26,7 @@ type ArrayType struct {
 
 func (at *ArrayType) expressionNode()      {}
 func (at *ArrayType) TokenLiteral() string { return at.Token.Literal }
+func (at *ArrayType) Pos() token.Token     { return at.Token }
 func (at *ArrayType) String() string {
 	var out bytes.Buffer
 	out.WriteString("[")
@@ -41,6 +43,7 @@ type FunctionType struct {
 
 func (ft *FunctionType) expressionNode()      {}
 func (ft *FunctionType) TokenLiteral() string { return ft.Token.Literal }
+func (ft *FunctionType) Pos() token.Token     { return ft.Token }
 func (ft *FunctionType) String() string {
 	var out bytes.Buffer
 	params := []string{}
@@ -66,6 +69,7 @@ type TaggedType struct {
 
 func (tt *TaggedType) expressionNode()      {}
 func (tt *TaggedType) TokenLiteral() string { return tt.Token.Literal }
+func (tt *TaggedType) Pos() token.Token     { return tt.Token }
 func (tt *TaggedType) String() string {
 	return fmt.Sprintf("%s:%s", tt.Tag.String(), tt.Type.String())
 }
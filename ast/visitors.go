@@ -5,15 +5,22 @@ type Visitor interface {
 	VisitIdentifier(node *Identifier) interface{}
 	VisitLetStatement(node *LetStatement) interface{}
 	VisitReturnStatement(node *ReturnStatement) interface{}
+	VisitSleepStatement(node *SleepStatement) interface{}
+	VisitExitStatement(node *ExitStatement) interface{}
+	VisitAssertStatement(node *AssertStatement) interface{}
 	VisitExpressionStatement(node *ExpressionStatement) interface{}
+	VisitEmptyStatement(node *EmptyStatement) interface{}
 	VisitBlockStatement(node *BlockStatement) interface{}
 	VisitIfStatement(node *IfStatement) interface{}
 	VisitWhileStatement(node *WhileStatement) interface{}
 	VisitForStatement(node *ForStatement) interface{}
 	VisitPrefixExpression(node *PrefixExpression) interface{}
 	VisitInfixExpression(node *InfixExpression) interface{}
+	VisitPostfixExpression(node *PostfixExpression) interface{}
+	VisitCommaExpression(node *CommaExpression) interface{}
 	VisitCallExpression(node *CallExpression) interface{}
 	VisitIndexExpression(node *IndexExpression) interface{}
+	VisitEmitExpression(node *EmitExpression) interface{}
 	VisitIntegerLiteral(node *IntegerLiteral) interface{}
 	VisitFloatLiteral(node *FloatLiteral) interface{}
 	VisitStringLiteral(node *StringLiteral) interface{}
@@ -29,10 +36,39 @@ type Visitor interface {
 	VisitEnumDeclaration(node *EnumDeclaration) interface{}
 	VisitIncludeDirective(node *IncludeDirective) interface{}
 	VisitDefineDirective(node *DefineDirective) interface{}
-	VisitIfDefDirective(node *IfDefDirective) interface{}
+	VisitConditionalDirective(node *ConditionalDirective) interface{}
+	VisitEmitDirective(node *EmitDirective) interface{}
+	VisitPragmaDirective(node *PragmaDirective) interface{}
+	VisitEndInputDirective(node *EndInputDirective) interface{}
+	VisitUndefDirective(node *UndefDirective) interface{}
+	VisitErrorDirective(node *ErrorDirective) interface{}
+	VisitWarningDirective(node *WarningDirective) interface{}
+	VisitAssertDirective(node *AssertDirective) interface{}
+	VisitLineDirective(node *LineDirective) interface{}
+	VisitFileDirective(node *FileDirective) interface{}
+	VisitSectionDirective(node *SectionDirective) interface{}
 	VisitNativeFunctionDeclaration(node *NativeFunctionDeclaration) interface{}
-	VisitStateDeclaration(node *StateDeclaration) interface{}
+	VisitStateStatement(node *StateStatement) interface{}
 	VisitFunctionDeclaration(node *FunctionDeclaration) interface{}
+	VisitHookDeclaration(node *HookDeclaration) interface{}
+	VisitForeachStatement(node *ForeachStatement) interface{}
+	VisitIteratorDeclaration(node *IteratorDeclaration) interface{}
+	VisitTimerDeclaration(node *TimerDeclaration) interface{}
+	VisitTimerCallExpression(node *TimerCallExpression) interface{}
+	VisitStopExpression(node *StopExpression) interface{}
+	VisitInlineDeclaration(node *InlineDeclaration) interface{}
+	VisitUsingExpression(node *UsingExpression) interface{}
+	VisitRemoteFunctionDeclaration(node *RemoteFunctionDeclaration) interface{}
+	VisitLoadTextDeclaration(node *LoadTextDeclaration) interface{}
+	VisitTextIdentifier(node *TextIdentifier) interface{}
+	VisitYieldStatement(node *YieldStatement) interface{}
+	VisitInlineReturnStatement(node *InlineReturnStatement) interface{}
+	VisitSpecialIdentifier(node *SpecialIdentifier) interface{}
+	VisitVarArgsExpression(node *VarArgsExpression) interface{}
+	VisitCommandDeclaration(node *CommandDeclaration) interface{}
+	VisitSymbolOperatorExpression(node *SymbolOperatorExpression) interface{}
+	VisitStaticAssertExpression(node *StaticAssertExpression) interface{}
+	VisitPragmaExpression(node *PragmaExpression) interface{}
 }
 
 func (p *Program) Accept(v Visitor) interface{} {
@@ -51,10 +87,26 @@ func (rs *ReturnStatement) Accept(v Visitor) interface{} {
 	return v.VisitReturnStatement(rs)
 }
 
+func (ss *SleepStatement) Accept(v Visitor) interface{} {
+	return v.VisitSleepStatement(ss)
+}
+
+func (es *ExitStatement) Accept(v Visitor) interface{} {
+	return v.VisitExitStatement(es)
+}
+
+func (as *AssertStatement) Accept(v Visitor) interface{} {
+	return v.VisitAssertStatement(as)
+}
+
 func (es *ExpressionStatement) Accept(v Visitor) interface{} {
 	return v.VisitExpressionStatement(es)
 }
 
+func (es *EmptyStatement) Accept(v Visitor) interface{} {
+	return v.VisitEmptyStatement(es)
+}
+
 func (bs *BlockStatement) Accept(v Visitor) interface{} {
 	return v.VisitBlockStatement(bs)
 }
@@ -79,6 +131,14 @@ func (ie *InfixExpression) Accept(v Visitor) interface{} {
 	return v.VisitInfixExpression(ie)
 }
 
+func (pe *PostfixExpression) Accept(v Visitor) interface{} {
+	return v.VisitPostfixExpression(pe)
+}
+
+func (ce *CommaExpression) Accept(v Visitor) interface{} {
+	return v.VisitCommaExpression(ce)
+}
+
 func (ce *CallExpression) Accept(v Visitor) interface{} {
 	return v.VisitCallExpression(ce)
 }
@@ -87,6 +147,10 @@ func (ie *IndexExpression) Accept(v Visitor) interface{} {
 	return v.VisitIndexExpression(ie)
 }
 
+func (ee *EmitExpression) Accept(v Visitor) interface{} {
+	return v.VisitEmitExpression(ee)
+}
+
 func (il *IntegerLiteral) Accept(v Visitor) interface{} {
 	return v.VisitIntegerLiteral(il)
 }
@@ -147,17 +211,133 @@ func (dd *DefineDirective) Accept(v Visitor) interface{} {
 	return v.VisitDefineDirective(dd)
 }
 
-func (idd *IfDefDirective) Accept(v Visitor) interface{} {
-	return v.VisitIfDefDirective(idd)
+func (cd *ConditionalDirective) Accept(v Visitor) interface{} {
+	return v.VisitConditionalDirective(cd)
+}
+
+func (ed *EmitDirective) Accept(v Visitor) interface{} {
+	return v.VisitEmitDirective(ed)
+}
+
+func (pd *PragmaDirective) Accept(v Visitor) interface{} {
+	return v.VisitPragmaDirective(pd)
+}
+
+func (eid *EndInputDirective) Accept(v Visitor) interface{} {
+	return v.VisitEndInputDirective(eid)
+}
+
+func (ud *UndefDirective) Accept(v Visitor) interface{} {
+	return v.VisitUndefDirective(ud)
+}
+
+func (ed *ErrorDirective) Accept(v Visitor) interface{} {
+	return v.VisitErrorDirective(ed)
+}
+
+func (wd *WarningDirective) Accept(v Visitor) interface{} {
+	return v.VisitWarningDirective(wd)
+}
+
+func (ad *AssertDirective) Accept(v Visitor) interface{} {
+	return v.VisitAssertDirective(ad)
+}
+
+func (ld *LineDirective) Accept(v Visitor) interface{} {
+	return v.VisitLineDirective(ld)
+}
+
+func (fd *FileDirective) Accept(v Visitor) interface{} {
+	return v.VisitFileDirective(fd)
+}
+
+func (sd *SectionDirective) Accept(v Visitor) interface{} {
+	return v.VisitSectionDirective(sd)
 }
 
 func (nfd *NativeFunctionDeclaration) Accept(v Visitor) interface{} {
 	return v.VisitNativeFunctionDeclaration(nfd)
 }
 
-func (sd *StateDeclaration) Accept(v Visitor) interface{} {
-	return v.VisitStateDeclaration(sd)
+func (ss *StateStatement) Accept(v Visitor) interface{} {
+	return v.VisitStateStatement(ss)
 }
 func (fd *FunctionDeclaration) Accept(v Visitor) interface{} {
 	return v.VisitFunctionDeclaration(fd)
 }
+
+func (hd *HookDeclaration) Accept(v Visitor) interface{} {
+	return v.VisitHookDeclaration(hd)
+}
+
+func (fs *ForeachStatement) Accept(v Visitor) interface{} {
+	return v.VisitForeachStatement(fs)
+}
+
+func (id *IteratorDeclaration) Accept(v Visitor) interface{} {
+	return v.VisitIteratorDeclaration(id)
+}
+
+func (td *TimerDeclaration) Accept(v Visitor) interface{} {
+	return v.VisitTimerDeclaration(td)
+}
+
+func (tc *TimerCallExpression) Accept(v Visitor) interface{} {
+	return v.VisitTimerCallExpression(tc)
+}
+
+func (se *StopExpression) Accept(v Visitor) interface{} {
+	return v.VisitStopExpression(se)
+}
+
+func (id *InlineDeclaration) Accept(v Visitor) interface{} {
+	return v.VisitInlineDeclaration(id)
+}
+
+func (ue *UsingExpression) Accept(v Visitor) interface{} {
+	return v.VisitUsingExpression(ue)
+}
+
+func (rd *RemoteFunctionDeclaration) Accept(v Visitor) interface{} {
+	return v.VisitRemoteFunctionDeclaration(rd)
+}
+
+func (ld *LoadTextDeclaration) Accept(v Visitor) interface{} {
+	return v.VisitLoadTextDeclaration(ld)
+}
+
+func (ti *TextIdentifier) Accept(v Visitor) interface{} {
+	return v.VisitTextIdentifier(ti)
+}
+
+func (ys *YieldStatement) Accept(v Visitor) interface{} {
+	return v.VisitYieldStatement(ys)
+}
+
+func (ir *InlineReturnStatement) Accept(v Visitor) interface{} {
+	return v.VisitInlineReturnStatement(ir)
+}
+
+func (si *SpecialIdentifier) Accept(v Visitor) interface{} {
+	return v.VisitSpecialIdentifier(si)
+}
+
+func (va *VarArgsExpression) Accept(v Visitor) interface{} {
+	return v.VisitVarArgsExpression(va)
+}
+
+func (cd *CommandDeclaration) Accept(v Visitor) interface{} {
+	return v.VisitCommandDeclaration(cd)
+}
+
+func (so *SymbolOperatorExpression) Accept(v Visitor) interface{} {
+	return v.VisitSymbolOperatorExpression(so)
+}
+
+func (sa *StaticAssertExpression) Accept(v Visitor) interface{} {
+	return v.VisitStaticAssertExpression(sa)
+}
+
+func (pe *PragmaExpression) Accept(v Visitor) interface{} {
+	return v.VisitPragmaExpression(pe)
+}
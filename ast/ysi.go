@@ -0,0 +1,390 @@
+package ast
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/Tramposo1312/pawn-parser/token"
+)
+
+// HookDeclaration is a y_hooks hook. "hook OnPlayerConnect(playerid)"
+// adds a body to a callback, run along with every other hook of it;
+// "hook function SetPlayerHealth(playerid, Float:health)" wraps a
+// function instead.
+type HookDeclaration struct {
+	Token      token.Token //  'hook'
+	Function   bool        //  'hook function'
+	Name       *Identifier //  the hooked callback or function
+	Parameters []*Identifier
+	Body       *BlockStatement
+}
+
+// ForeachStatement is a y_iterate loop, "foreach (new i : Player) { ... }".
+// Iterator is the iterator as written: a name such as Player, an element
+// of an iterator array such as PlayerVehicles[playerid], or a special
+// iterator call such as Range(0, 10).
+type ForeachStatement struct {
+	Token    token.Token //  'foreach'
+	Declared bool        //  the variable is declared by the loop with 'new'
+	Variable *Identifier
+	Iterator Expression
+	Body     *BlockStatement
+}
+
+// IteratorDeclaration declares a y_iterate iterator, "new
+// Iterator:Admins<MAX_PLAYERS>;", or an array of them,
+// "new Iterator:PlayerVehicles[MAX_PLAYERS]<MAX_VEHICLES>;".
+type IteratorDeclaration struct {
+	Token token.Token //  'new'
+	Name  *Identifier //  without the Iterator: tag
+	Count Expression  //  the array size; nil for a single iterator
+	Size  Expression  //  the most values the iterator can hold
+}
+
+// TimerDeclaration is a y_timers function run on a timer. "timer
+// SaveAll[60000]()" is only started by defer or repeat; "task
+// Tick[1000]()" runs from the start of the mode and "ptask
+// PlayerTick[500](playerid)" for every connected player. Interval is in
+// milliseconds.
+type TimerDeclaration struct {
+	Token      token.Token //  'timer', 'task' or 'ptask'
+	Name       *Identifier
+	Interval   Expression
+	Parameters []*Identifier
+	Body       *BlockStatement
+}
+
+// TimerCallExpression starts a timer: "defer SaveAll()" calls it once
+// after its interval, "repeat SaveAll()" every interval. Delay overrides
+// the declared interval, as in "defer SaveAll[5000]()".
+type TimerCallExpression struct {
+	Token     token.Token //  'defer' or 'repeat'
+	Timer     *Identifier
+	Delay     Expression
+	Arguments []Expression
+}
+
+// StopExpression is "stop t", which kills the timer started by repeat or
+// defer whose handle is in t.
+type StopExpression struct {
+	Token token.Token //  'stop'
+	Timer Expression
+}
+
+// InlineDeclaration is a y_inline function, declared inside another
+// function's body. It can use the enclosing function's variables, and is
+// passed to functions that call it back with "using inline Name".
+type InlineDeclaration struct {
+	Token      token.Token //  'inline'
+	Name       *Identifier
+	Parameters []*Identifier
+	Body       *BlockStatement
+}
+
+// UsingExpression passes a callback by name: "using inline Name" for an
+// inline function, "using public Name" or "using callback Name" for a
+// public function.
+type UsingExpression struct {
+	Token token.Token //  'using'
+	Kind  string      //  "inline", "public" or "callback"
+	Name  *Identifier
+}
+
+// RemoteFunctionDeclaration is a function shared between scripts. y_master
+// declares one with "foreign" in the scripts that call it and defines it
+// with "global" in the script that owns it; y_remote defines one with
+// "remotefunc". Tag is the return tag, such as void or string, if given.
+type RemoteFunctionDeclaration struct {
+	Token      token.Token //  'foreign', 'global' or 'remotefunc'
+	Tag        string
+	Name       *Identifier
+	Parameters []*Identifier
+	Body       *BlockStatement // nil for 'foreign'
+}
+
+// Signature returns the declaration without its specifier or body, e.g.
+// "void:Kick(playerid, string:reason[])", which has to be the same in
+// every script that declares the function.
+func (rd *RemoteFunctionDeclaration) Signature() string {
+	params := []string{}
+	for _, p := range rd.Parameters {
+		params = append(params, p.String())
+	}
+	name := rd.Name.String()
+	if rd.Tag != "" {
+		name = rd.Tag + ":" + name
+	}
+	return name + "(" + strings.Join(params, ", ") + ")"
+}
+
+// LoadTextDeclaration is y_text's "loadtext core[messages], core[errors];",
+// which makes sections of the text files available to the Text_ functions
+// in the rest of the function.
+type LoadTextDeclaration struct {
+	Token    token.Token //  'loadtext'
+	Sections []*TextSection
+}
+
+// TextSection is one "file[section]" of a loadtext declaration.
+type TextSection struct {
+	Token   token.Token // the file name
+	File    string
+	Section string
+}
+
+// TextIdentifier names a y_text string, as in Text_Send(playerid, $WELCOME).
+type TextIdentifier struct {
+	Token token.Token //  the '$WELCOME' token
+	Name  string      //  without the '$'
+}
+
+// YieldStatement is "yield 1;" or "@yield 1;", which gives a y_inline
+// caller its return value without ending the function.
+type YieldStatement struct {
+	Token token.Token //  'yield' or '@yield'
+	Value Expression
+}
+
+// InlineReturnStatement is "@return 1;", which returns from the public
+// function an inline function was called through rather than from the
+// inline function itself.
+type InlineReturnStatement struct {
+	Token token.Token //  '@return'
+	Value Expression
+}
+
+// SpecialIdentifier is a name starting with '@', which YSI reserves for
+// names generated by its macros, such as @yield or @Timer.
+type SpecialIdentifier struct {
+	Token token.Token //  the '@name' token
+	Name  string      //  without the '@'
+}
+
+// VarArgsExpression is y_va's "___(n)", which passes on the variable
+// arguments of the function it is in, starting from argument n. Start is
+// nil for a bare "___", which passes them all.
+type VarArgsExpression struct {
+	Token token.Token //  '___'
+	Start Expression
+}
+
+// CommandDeclaration is a chat command of a command processor, written
+// with its macro, such as "CMD:kick(playerid, params[])" for zcmd or
+// Pawn.CMD, "COMMAND:ban(...)" or y_commands' "YCMD:help(...)", or as the
+// "public cmd_kick(...)" zcmd's macros expand to. The function is as
+// parsed, with the macro prefix still in its name.
+type CommandDeclaration struct {
+	*FunctionDeclaration
+	Processor string //  "CMD", "COMMAND", "YCMD", or "zcmd" for a cmd_ public
+	Command   string //  the command typed after the '/', e.g. "kick"
+}
+
+func (hd *HookDeclaration) String() string {
+	var out bytes.Buffer
+	params := []string{}
+	for _, p := range hd.Parameters {
+		params = append(params, p.String())
+	}
+	out.WriteString("hook ")
+	if hd.Function {
+		out.WriteString("function ")
+	}
+	out.WriteString(hd.Name.String())
+	out.WriteString("(")
+	out.WriteString(strings.Join(params, ", "))
+	out.WriteString(") ")
+	out.WriteString(hd.Body.String())
+	return out.String()
+}
+
+func (fs *ForeachStatement) String() string {
+	var out bytes.Buffer
+	out.WriteString("foreach (")
+	if fs.Declared {
+		out.WriteString("new ")
+	}
+	out.WriteString(fs.Variable.String())
+	out.WriteString(" : ")
+	out.WriteString(fs.Iterator.String())
+	out.WriteString(") ")
+	out.WriteString(fs.Body.String())
+	return out.String()
+}
+
+func (id *IteratorDeclaration) String() string {
+	var out bytes.Buffer
+	out.WriteString("new Iterator:")
+	out.WriteString(id.Name.String())
+	if id.Count != nil {
+		out.WriteString("[" + id.Count.String() + "]")
+	}
+	out.WriteString("<" + id.Size.String() + ">;")
+	return out.String()
+}
+
+func (td *TimerDeclaration) String() string {
+	var out bytes.Buffer
+	params := []string{}
+	for _, p := range td.Parameters {
+		params = append(params, p.String())
+	}
+	out.WriteString(td.TokenLiteral() + " ")
+	out.WriteString(td.Name.String())
+	out.WriteString("[" + td.Interval.String() + "]")
+	out.WriteString("(")
+	out.WriteString(strings.Join(params, ", "))
+	out.WriteString(") ")
+	out.WriteString(td.Body.String())
+	return out.String()
+}
+
+func (tc *TimerCallExpression) String() string {
+	var out bytes.Buffer
+	args := []string{}
+	for _, a := range tc.Arguments {
+		args = append(args, a.String())
+	}
+	out.WriteString(tc.TokenLiteral() + " ")
+	out.WriteString(tc.Timer.String())
+	if tc.Delay != nil {
+		out.WriteString("[" + tc.Delay.String() + "]")
+	}
+	out.WriteString("(")
+	out.WriteString(strings.Join(args, ", "))
+	out.WriteString(")")
+	return out.String()
+}
+
+func (se *StopExpression) String() string {
+	return "stop " + se.Timer.String()
+}
+
+func (id *InlineDeclaration) String() string {
+	var out bytes.Buffer
+	params := []string{}
+	for _, p := range id.Parameters {
+		params = append(params, p.String())
+	}
+	out.WriteString("inline ")
+	out.WriteString(id.Name.String())
+	out.WriteString("(")
+	out.WriteString(strings.Join(params, ", "))
+	out.WriteString(") ")
+	out.WriteString(id.Body.String())
+	return out.String()
+}
+
+func (ue *UsingExpression) String() string {
+	return "using " + ue.Kind + " " + ue.Name.String()
+}
+
+func (rd *RemoteFunctionDeclaration) String() string {
+	if rd.Body == nil {
+		return rd.TokenLiteral() + " " + rd.Signature() + ";"
+	}
+	return rd.TokenLiteral() + " " + rd.Signature() + " " + rd.Body.String()
+}
+
+func (ld *LoadTextDeclaration) String() string {
+	sections := []string{}
+	for _, section := range ld.Sections {
+		sections = append(sections, section.File+"["+section.Section+"]")
+	}
+	return "loadtext " + strings.Join(sections, ", ") + ";"
+}
+
+func (ti *TextIdentifier) String() string {
+	return "$" + ti.Name
+}
+
+func (ys *YieldStatement) String() string {
+	var out bytes.Buffer
+	out.WriteString(ys.TokenLiteral())
+	if ys.Value != nil {
+		out.WriteString(" " + ys.Value.String())
+	}
+	out.WriteString(";")
+	return out.String()
+}
+
+func (ir *InlineReturnStatement) String() string {
+	var out bytes.Buffer
+	out.WriteString(ir.TokenLiteral())
+	if ir.Value != nil {
+		out.WriteString(" " + ir.Value.String())
+	}
+	out.WriteString(";")
+	return out.String()
+}
+
+func (si *SpecialIdentifier) String() string {
+	return "@" + si.Name
+}
+
+func (va *VarArgsExpression) String() string {
+	if va.Start == nil {
+		return va.TokenLiteral()
+	}
+	return va.TokenLiteral() + "(" + va.Start.String() + ")"
+}
+
+// ====
+func (hd *HookDeclaration) statementNode()       {}
+func (hd *HookDeclaration) TokenLiteral() string { return hd.Token.Literal }
+func (hd *HookDeclaration) Pos() token.Token     { return hd.Token }
+
+func (fs *ForeachStatement) statementNode()       {}
+func (fs *ForeachStatement) TokenLiteral() string { return fs.Token.Literal }
+func (fs *ForeachStatement) Pos() token.Token     { return fs.Token }
+
+func (id *IteratorDeclaration) statementNode()       {}
+func (id *IteratorDeclaration) TokenLiteral() string { return id.Token.Literal }
+func (id *IteratorDeclaration) Pos() token.Token     { return id.Token }
+
+func (td *TimerDeclaration) statementNode()       {}
+func (td *TimerDeclaration) TokenLiteral() string { return td.Token.Literal }
+func (td *TimerDeclaration) Pos() token.Token     { return td.Token }
+
+func (tc *TimerCallExpression) expressionNode()      {}
+func (tc *TimerCallExpression) TokenLiteral() string { return tc.Token.Literal }
+func (tc *TimerCallExpression) Pos() token.Token     { return tc.Token }
+
+func (se *StopExpression) expressionNode()      {}
+func (se *StopExpression) TokenLiteral() string { return se.Token.Literal }
+func (se *StopExpression) Pos() token.Token     { return se.Token }
+
+func (id *InlineDeclaration) statementNode()       {}
+func (id *InlineDeclaration) TokenLiteral() string { return id.Token.Literal }
+func (id *InlineDeclaration) Pos() token.Token     { return id.Token }
+
+func (ue *UsingExpression) expressionNode()      {}
+func (ue *UsingExpression) TokenLiteral() string { return ue.Token.Literal }
+func (ue *UsingExpression) Pos() token.Token     { return ue.Token }
+
+func (rd *RemoteFunctionDeclaration) statementNode()       {}
+func (rd *RemoteFunctionDeclaration) TokenLiteral() string { return rd.Token.Literal }
+func (rd *RemoteFunctionDeclaration) Pos() token.Token     { return rd.Token }
+
+func (ld *LoadTextDeclaration) statementNode()       {}
+func (ld *LoadTextDeclaration) TokenLiteral() string { return ld.Token.Literal }
+func (ld *LoadTextDeclaration) Pos() token.Token     { return ld.Token }
+
+func (ti *TextIdentifier) expressionNode()      {}
+func (ti *TextIdentifier) TokenLiteral() string { return ti.Token.Literal }
+func (ti *TextIdentifier) Pos() token.Token     { return ti.Token }
+
+func (ys *YieldStatement) statementNode()       {}
+func (ys *YieldStatement) TokenLiteral() string { return ys.Token.Literal }
+func (ys *YieldStatement) Pos() token.Token     { return ys.Token }
+
+func (ir *InlineReturnStatement) statementNode()       {}
+func (ir *InlineReturnStatement) TokenLiteral() string { return ir.Token.Literal }
+func (ir *InlineReturnStatement) Pos() token.Token     { return ir.Token }
+
+func (si *SpecialIdentifier) expressionNode()      {}
+func (si *SpecialIdentifier) TokenLiteral() string { return si.Token.Literal }
+func (si *SpecialIdentifier) Pos() token.Token     { return si.Token }
+
+func (va *VarArgsExpression) expressionNode()      {}
+func (va *VarArgsExpression) TokenLiteral() string { return va.Token.Literal }
+func (va *VarArgsExpression) Pos() token.Token     { return va.Token }
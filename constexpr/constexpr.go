@@ -0,0 +1,327 @@
+// constexpr/constexpr.go
+
+// Package constexpr evaluates Pawn constant expressions, such as the
+// conditions of #if and #elseif, over a line of tokens.
+package constexpr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Tramposo1312/pawn-parser/precedence"
+	"github.com/Tramposo1312/pawn-parser/token"
+)
+
+// Lookup resolves a name used in an expression. ok is false when the name
+// is not known; "defined NAME" is true exactly when ok is.
+type Lookup func(name string) (value int64, ok bool)
+
+// Eval evaluates tokens as a single constant expression. Values are Pawn
+// cells, so arithmetic wraps at 32 bits. lookup may be nil when the
+// expression is not expected to contain names.
+func Eval(tokens []token.Token, lookup Lookup) (int64, error) {
+	if lookup == nil {
+		lookup = func(string) (int64, bool) { return 0, false }
+	}
+	e := &evaluator{tokens: tokens, lookup: lookup}
+	if len(tokens) == 0 {
+		return 0, fmt.Errorf("expected an expression")
+	}
+
+	value, err := e.expression(precedence.LOWEST)
+	if err != nil {
+		return 0, err
+	}
+	if e.pos < len(e.tokens) {
+		return 0, fmt.Errorf("unexpected %q in constant expression", e.tokens[e.pos].Literal)
+	}
+	return value, nil
+}
+
+type evaluator struct {
+	tokens []token.Token
+	pos    int
+	lookup Lookup
+}
+
+func (e *evaluator) peek() token.Token {
+	if e.pos >= len(e.tokens) {
+		return token.Token{Type: token.EOF}
+	}
+	return e.tokens[e.pos]
+}
+
+func (e *evaluator) next() token.Token {
+	tok := e.peek()
+	e.pos++
+	return tok
+}
+
+func (e *evaluator) expect(t token.TokenType) error {
+	if tok := e.next(); tok.Type != t {
+		if tok.Type == token.EOF {
+			return fmt.Errorf("expected %s, got end of expression", t)
+		}
+		return fmt.Errorf("expected %s, got %q", t, tok.Literal)
+	}
+	return nil
+}
+
+// expression evaluates binary operators binding tighter than prec, by
+// precedence climbing over the parser's precedence table.
+func (e *evaluator) expression(prec int) (int64, error) {
+	left, err := e.unary()
+	if err != nil {
+		return 0, err
+	}
+
+	for {
+		op := e.peek()
+		opPrec := precedence.GetPrecedence(op.Type)
+		if opPrec <= prec || opPrec >= precedence.PREFIX || opPrec == precedence.ASSIGN {
+			return left, nil
+		}
+		e.next()
+
+		if op.Type == token.QUESTION {
+			// The conditional operator is right associative.
+			then, err := e.expression(precedence.TERNARY - 1)
+			if err != nil {
+				return 0, err
+			}
+			if err := e.expect(token.COLON); err != nil {
+				return 0, err
+			}
+			otherwise, err := e.expression(precedence.TERNARY - 1)
+			if err != nil {
+				return 0, err
+			}
+			if left != 0 {
+				left = then
+			} else {
+				left = otherwise
+			}
+			continue
+		}
+
+		right, err := e.expression(opPrec)
+		if err != nil {
+			return 0, err
+		}
+		left, err = binary(op, left, right)
+		if err != nil {
+			return 0, err
+		}
+	}
+}
+
+func (e *evaluator) unary() (int64, error) {
+	tok := e.next()
+	switch tok.Type {
+	case token.MINUS, token.NOT, token.TILDE, token.PLUS:
+		value, err := e.unary()
+		if err != nil {
+			return 0, err
+		}
+		switch tok.Type {
+		case token.MINUS:
+			return cell(-value), nil
+		case token.NOT:
+			return boolean(value == 0), nil
+		case token.TILDE:
+			return cell(^value), nil
+		}
+		return value, nil
+	case token.LPAREN:
+		value, err := e.expression(precedence.LOWEST)
+		if err != nil {
+			return 0, err
+		}
+		return value, e.expect(token.RPAREN)
+	case token.INT:
+		return parseInt(tok.Literal)
+	case token.CHAR:
+		return parseChar(tok.Literal)
+	case token.TRUE:
+		return 1, nil
+	case token.FALSE:
+		return 0, nil
+	case token.DEFINED:
+		return e.defined()
+	case token.IDENT:
+		return e.name(tok)
+	case token.EOF:
+		return 0, fmt.Errorf("unexpected end of constant expression")
+	}
+	return 0, fmt.Errorf("unexpected %q in constant expression", tok.Literal)
+}
+
+// defined evaluates "defined NAME" or "defined(NAME)".
+func (e *evaluator) defined() (int64, error) {
+	parens := e.peek().Type == token.LPAREN
+	if parens {
+		e.next()
+	}
+	ident := e.next()
+	if ident.Type != token.IDENT {
+		return 0, fmt.Errorf("expected a name after defined")
+	}
+	if parens {
+		if err := e.expect(token.RPAREN); err != nil {
+			return 0, err
+		}
+	}
+	_, ok := e.lookup(ident.Literal)
+	return boolean(ok), nil
+}
+
+// name evaluates an identifier: a constant, or a tag override such as
+// "_:" or "Float:MAX" applied to what follows.
+func (e *evaluator) name(tok token.Token) (int64, error) {
+	// The lexer reads a tag and what follows it as one identifier.
+	name := tok.Literal
+	if i := strings.LastIndexByte(name, ':'); i >= 0 {
+		name = name[i+1:]
+		switch {
+		case name == "":
+			return e.unary()
+		case name[0] >= '0' && name[0] <= '9':
+			return parseInt(name)
+		}
+	}
+
+	value, ok := e.lookup(name)
+	if !ok {
+		return 0, fmt.Errorf("undefined symbol %s", name)
+	}
+	return value, nil
+}
+
+func binary(op token.Token, left, right int64) (int64, error) {
+	switch op.Type {
+	case token.LOR:
+		return boolean(left != 0 || right != 0), nil
+	case token.LAND:
+		return boolean(left != 0 && right != 0), nil
+	case token.OR:
+		return left | right, nil
+	case token.XOR:
+		return left ^ right, nil
+	case token.AND:
+		return left & right, nil
+	case token.EQ:
+		return boolean(left == right), nil
+	case token.NEQ:
+		return boolean(left != right), nil
+	case token.LT:
+		return boolean(left < right), nil
+	case token.GT:
+		return boolean(left > right), nil
+	case token.LTE:
+		return boolean(left <= right), nil
+	case token.GTE:
+		return boolean(left >= right), nil
+	case token.SHL:
+		return cell(left << uint64(right&63)), nil
+	case token.SHR:
+		return left >> uint64(right&63), nil
+	case token.SHRU:
+		return int64(uint32(left) >> uint64(right&63)), nil
+	case token.PLUS:
+		return cell(left + right), nil
+	case token.MINUS:
+		return cell(left - right), nil
+	case token.MUL:
+		return cell(left * right), nil
+	case token.QUO, token.REM:
+		if right == 0 {
+			return 0, fmt.Errorf("division by zero")
+		}
+		// Pawn division floors rather than truncating.
+		q, r := left/right, left%right
+		if r != 0 && (r < 0) != (right < 0) {
+			q--
+			r += right
+		}
+		if op.Type == token.QUO {
+			return cell(q), nil
+		}
+		return r, nil
+	}
+	return 0, fmt.Errorf("operator %s is not allowed in a constant expression", op.Literal)
+}
+
+// cell wraps a value to a 32-bit Pawn cell.
+func cell(v int64) int64 {
+	return int64(int32(v))
+}
+
+func boolean(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func parseInt(literal string) (int64, error) {
+	base, digits := 10, literal
+	switch {
+	case strings.HasPrefix(literal, "0x"), strings.HasPrefix(literal, "0X"):
+		base, digits = 16, literal[2:]
+	case strings.HasPrefix(literal, "0b"), strings.HasPrefix(literal, "0B"):
+		base, digits = 2, literal[2:]
+	}
+	value, err := strconv.ParseUint(strings.ReplaceAll(digits, "_", ""), base, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %s", literal)
+	}
+	return cell(int64(value)), nil
+}
+
+// parseChar returns the value of a character literal such as 'a', '\n'
+// or '\65;'.
+func parseChar(literal string) (int64, error) {
+	body := strings.TrimSuffix(strings.TrimPrefix(literal, "'"), "'")
+	if body == "" {
+		return 0, fmt.Errorf("empty character literal")
+	}
+	if body[0] != '\\' {
+		if len(body) != 1 {
+			return 0, fmt.Errorf("invalid character literal %s", literal)
+		}
+		return int64(body[0]), nil
+	}
+
+	esc := body[1:]
+	switch esc {
+	case "a":
+		return 7, nil
+	case "b":
+		return 8, nil
+	case "e":
+		return 27, nil
+	case "f":
+		return 12, nil
+	case "n":
+		return 10, nil
+	case "r":
+		return 13, nil
+	case "t":
+		return 9, nil
+	case "v":
+		return 11, nil
+	case `\`, "'", `"`, "%":
+		return int64(esc[0]), nil
+	}
+
+	base := 10
+	if strings.HasPrefix(esc, "x") {
+		base, esc = 16, esc[1:]
+	}
+	value, err := strconv.ParseUint(strings.TrimSuffix(esc, ";"), base, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid character literal %s", literal)
+	}
+	return int64(value), nil
+}
@@ -0,0 +1,89 @@
+package constexpr
+
+import (
+	"testing"
+
+	"github.com/Tramposo1312/pawn-parser/lexer"
+	"github.com/Tramposo1312/pawn-parser/token"
+)
+
+func tokenize(input string) []token.Token {
+	l := lexer.New(input)
+	tokens := []token.Token{}
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		tokens = append(tokens, tok)
+	}
+	return tokens
+}
+
+func TestEval(t *testing.T) {
+	constants := map[string]int64{"MAX_PLAYERS": 500, "DEBUG": 0}
+	lookup := func(name string) (int64, bool) {
+		value, ok := constants[name]
+		return value, ok
+	}
+
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"1", 1},
+		{"0x1F", 31},
+		{"0b101", 5},
+		{"'a'", 97},
+		{"'\\n'", 10},
+		{"1 + 2 * 3", 7},
+		{"(1 + 2) * 3", 9},
+		{"7 / 2", 3},
+		{"-7 / 2", -4},
+		{"-7 % 2", 1},
+		{"1 << 4 | 1", 17},
+		{"-1 >>> 28", 15},
+		{"-16 >> 2", -4},
+		{"~0", -1},
+		{"!5", 0},
+		{"0x7FFFFFFF + 1", -2147483648},
+		{"MAX_PLAYERS > 100", 1},
+		{"MAX_PLAYERS >= 500 && MAX_PLAYERS != 1000", 1},
+		{"defined MAX_PLAYERS", 1},
+		{"defined(DEBUG) && !DEBUG", 1},
+		{"defined UNKNOWN || 0", 0},
+		{"!defined UNKNOWN", 1},
+		{"_:MAX_PLAYERS", 500},
+		{"_:-1", -1},
+		{"1 ? 2 : 3", 2},
+		{"0 ? 2 : 1 ? 4 : 5", 4},
+		{"true + true", 2},
+	}
+
+	for _, tt := range tests {
+		got, err := Eval(tokenize(tt.input), lookup)
+		if err != nil {
+			t.Errorf("Eval(%q) failed: %s", tt.input, err)
+			continue
+		}
+		if got != tt.expected {
+			t.Errorf("Eval(%q) = %d, expected %d", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestEvalErrors(t *testing.T) {
+	tests := []string{
+		"",
+		"1 +",
+		"(1",
+		"1 / 0",
+		"UNKNOWN > 1",
+		"x = 1",
+		"1 2",
+		"\"text\"",
+		"defined 1",
+	}
+
+	for _, input := range tests {
+		if _, err := Eval(tokenize(input), nil); err == nil {
+			t.Errorf("expected an error for %q", input)
+		}
+	}
+}
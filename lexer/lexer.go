@@ -14,6 +14,17 @@ type Lexer struct {
 	line         int
 	column       int
 	errors       []string
+
+	// newlines makes NextToken report line ends as token.NEWLINE.
+	newlines bool
+
+	// continued records that the whitespace before the current token
+	// crossed a line continuation.
+	continued bool
+
+	// file is recorded on every token so that tokens from different
+	// source files can be told apart once they are merged.
+	file string
 }
 
 func New(input string) *Lexer {
@@ -27,6 +38,27 @@ func New(input string) *Lexer {
 	return l
 }
 
+// NewFile returns a lexer for the contents of the named file. Every token
+// it produces has its File set to filename.
+func NewFile(filename, input string) *Lexer {
+	l := New(input)
+	l.file = filename
+	return l
+}
+
+// File returns the name given to NewFile, or "" for a lexer made by New.
+func (l *Lexer) File() string {
+	return l.file
+}
+
+// ReportNewlines makes NextToken return a token.NEWLINE at the end of every
+// line instead of skipping it. The preprocessor needs this to find where a
+// directive ends. Lines joined with a trailing backslash never produce a
+// NEWLINE.
+func (l *Lexer) ReportNewlines(on bool) {
+	l.newlines = on
+}
+
 func (l *Lexer) peekChar() byte {
 	if l.readPosition >= len(l.input) {
 		return 0
@@ -35,11 +67,28 @@ func (l *Lexer) peekChar() byte {
 }
 
 func (l *Lexer) NextToken() token.Token {
+	l.continued = false
+	tok := l.nextToken()
+	tok.File = l.file
+	tok.Continued = l.continued
+	return tok
+}
+
+func (l *Lexer) nextToken() token.Token {
 	var tok token.Token
 
 	l.skipWhitespace()
 
+	tok.Line = l.line
+	tok.Column = l.column
+
 	switch l.ch {
+	case '\n':
+		tok = l.makeToken(token.NEWLINE)
+		l.line++
+		l.column = 0
+		l.readChar()
+		return tok
 	case '=':
 		if l.peekChar() == '=' {
 			tok = l.makeTwoCharToken(token.EQL)
@@ -82,6 +131,10 @@ func (l *Lexer) NextToken() token.Token {
 		tok = l.handleGreaterThanOperator()
 	case ';':
 		tok = l.makeToken(token.SEMICOLON)
+	case '~':
+		tok = l.makeToken(token.TILDE)
+	case '?':
+		tok = l.makeToken(token.QUESTION)
 	case ':':
 		tok = l.makeToken(token.COLON)
 	case ',':
@@ -119,11 +172,27 @@ func (l *Lexer) NextToken() token.Token {
 	case '"':
 		tok.Type = token.STRING
 		tok.Literal = l.readString()
+		if l.ch != '"' {
+			return tok // unterminated: keep the end of line
+		}
 	case '\'':
 		tok.Type = token.CHAR
 		tok.Literal = l.readCharLiteral()
+		if l.ch != '\'' {
+			return tok
+		}
 	case '#':
 		return l.readPreprocessorDirective()
+	case '$':
+		if !isLetter(l.peekChar()) {
+			tok = l.makeToken(token.ILLEGAL)
+			l.errors = append(l.errors, fmt.Sprintf("Unexpected character: %c at line %d, column %d", l.ch, l.line, l.column))
+			break
+		}
+		l.readChar()
+		tok.Literal = "$" + l.readIdentifier()
+		tok.Type = token.TEXTID
+		return tok
 	case 0:
 		tok.Literal = ""
 		tok.Type = token.EOF
@@ -196,6 +265,9 @@ func (l *Lexer) handleGreaterThanOperator() token.Token {
 		l.readChar()
 		if l.peekChar() == '=' {
 			return l.makeTwoCharToken(token.SHR_ASSIGN)
+		} else if l.peekChar() == '>' {
+			l.readChar()
+			return token.Token{Type: token.SHRU, Literal: ">>>", Line: l.line, Column: l.column - 2}
 		}
 		return token.Token{Type: token.SHR, Literal: ">>", Line: l.line, Column: l.column - 1}
 	}
@@ -285,7 +357,11 @@ func (l *Lexer) readString() string {
 	position := l.position + 1 // Start after the opening quote
 	for {
 		l.readChar()
-		if l.ch == '"' || l.ch == 0 {
+		if l.ch == '\\' && l.peekChar() != '\n' {
+			l.readChar() // an escaped character
+			continue
+		}
+		if l.ch == '"' || l.ch == '\n' || l.ch == 0 {
 			break
 		}
 	}
@@ -307,12 +383,19 @@ func (l *Lexer) readCharLiteral() string {
 	position := l.position
 	for {
 		l.readChar()
-		if l.ch == '\'' || l.ch == 0 {
+		if l.ch == '\\' && l.peekChar() != '\n' {
+			l.readChar() // an escaped character
+			continue
+		}
+		if l.ch == '\'' || l.ch == '\n' || l.ch == 0 {
 			break
 		}
 	}
-	l.readChar() // read closing quote
-	return l.input[position:l.position]
+	// NextToken steps past the closing quote
+	if l.ch != '\'' {
+		return l.input[position:l.position]
+	}
+	return l.input[position : l.position+1]
 }
 
 func (l *Lexer) readLineComment() string {
@@ -334,6 +417,10 @@ func (l *Lexer) readBlockComment() string {
 			l.readChar()
 			break
 		}
+		if l.ch == '\n' {
+			l.line++
+			l.column = 0
+		}
 		l.readChar()
 	}
 	return l.input[position:l.position]
@@ -345,28 +432,54 @@ func (l *Lexer) readPreprocessorDirective() token.Token {
 
 	directive := l.readIdentifier()
 
-	switch directive {
-	case "include":
-		return token.Token{Type: token.INCLUDE, Literal: "#include", Line: l.line, Column: l.column - (l.position - startPosition)}
-	case "define":
-		return token.Token{Type: token.DEFINE, Literal: "#define", Line: l.line, Column: l.column - (l.position - startPosition)}
-	case "ifdef":
-		return token.Token{Type: token.IFDEF, Literal: "#ifdef", Line: l.line, Column: l.column - (l.position - startPosition)}
-	case "endif":
-		return token.Token{Type: token.ENDIF, Literal: "#endif", Line: l.line, Column: l.column - (l.position - startPosition)}
-	default:
-		return token.Token{Type: token.DIRECTIVE, Literal: "#" + directive, Line: l.line, Column: l.column - (l.position - startPosition)}
+	return token.Token{
+		Type:    token.LookupDirective(directive),
+		Literal: "#" + directive,
+		Line:    l.line,
+		Column:  l.column - (l.position - startPosition),
 	}
 }
 
 func (l *Lexer) skipWhitespace() {
-	for l.ch == ' ' || l.ch == '\t' || l.ch == '\n' || l.ch == '\r' {
-		if l.ch == '\n' {
+	for {
+		switch {
+		case l.ch == '\n' && l.newlines:
+			return
+		case l.ch == ' ' || l.ch == '\t' || l.ch == '\n' || l.ch == '\r':
+			if l.ch == '\n' {
+				l.line++
+				l.column = 0
+				l.continued = false
+			}
+			l.readChar()
+		case l.ch == '\\' && l.atLineContinuation():
+			for l.ch != '\n' {
+				l.readChar()
+			}
 			l.line++
 			l.column = 0
+			l.continued = true
+			l.readChar()
+		default:
+			return
+		}
+	}
+}
+
+// atLineContinuation reports whether the current backslash is the last
+// non-blank character on its line, joining it with the next one.
+func (l *Lexer) atLineContinuation() bool {
+	for i := l.readPosition; i < len(l.input); i++ {
+		switch l.input[i] {
+		case ' ', '\t', '\r':
+			continue
+		case '\n':
+			return true
+		default:
+			return false
 		}
-		l.readChar()
 	}
+	return false
 }
 
 func isLetter(ch byte) bool {
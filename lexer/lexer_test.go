@@ -173,7 +173,7 @@ new Float:myVariable = 10.5;
 		{token.DEFINE, "#define"},
 		{token.IDENT, "MAX_PLAYERS"},
 		{token.INT, "100"},
-		{token.DIRECTIVE, "#if"},
+		{token.HASH_IF, "#if"},
 		{token.DEFINED, "defined"},
 		{token.IDENT, "SOME_CONSTANT"},
 		{token.NEW, "new"},
@@ -204,3 +204,174 @@ new Float:myVariable = 10.5;
 		}
 	}
 }
+
+func TestTextIdentifiers(t *testing.T) {
+	input := `Text_Send(playerid, $WELCOME_MESSAGE); $ 1`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.IDENT, "Text_Send"},
+		{token.LPAREN, "("},
+		{token.IDENT, "playerid"},
+		{token.COMMA, ","},
+		{token.TEXTID, "$WELCOME_MESSAGE"},
+		{token.RPAREN, ")"},
+		{token.SEMICOLON, ";"},
+		{token.ILLEGAL, "$"},
+		{token.INT, "1"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType || tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - expected=%s %q, got=%s %q",
+				i, tt.expectedType, tt.expectedLiteral, tok.Type, tok.Literal)
+		}
+	}
+	if len(l.Errors()) != 1 {
+		t.Errorf("expected 1 error for the lone $. got=%v", l.Errors())
+	}
+}
+
+func TestTokenPositions(t *testing.T) {
+	input := `#emit LOAD.S.pri 12
+new str[] = "hi";
+c = 'A'
+x`
+
+	tests := []struct {
+		expectedType   token.TokenType
+		expectedLine   int
+		expectedColumn int
+	}{
+		{token.EMIT, 1, 1},
+		{token.IDENT, 1, 7},
+		{token.PERIOD, 1, 11},
+		{token.IDENT, 1, 12},
+		{token.PERIOD, 1, 13},
+		{token.IDENT, 1, 14},
+		{token.INT, 1, 18},
+		{token.NEW, 2, 1},
+		{token.IDENT, 2, 5},
+		{token.LBRACK, 2, 8},
+		{token.RBRACK, 2, 9},
+		{token.ASSIGN, 2, 11},
+		{token.STRING, 2, 13},
+		{token.SEMICOLON, 2, 17},
+		{token.IDENT, 3, 1},
+		{token.ASSIGN, 3, 3},
+		{token.CHAR, 3, 5},
+		{token.IDENT, 4, 1},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Line != tt.expectedLine || tok.Column != tt.expectedColumn {
+			t.Fatalf("tests[%d] - position wrong. expected=%d:%d, got=%d:%d",
+				i, tt.expectedLine, tt.expectedColumn, tok.Line, tok.Column)
+		}
+	}
+}
+
+func TestLineContinuation(t *testing.T) {
+	input := "#define A 1 + \\\n  2\nb \\\n\nc"
+
+	tests := []struct {
+		expectedLiteral   string
+		expectedLine      int
+		expectedContinued bool
+	}{
+		{"#define", 1, false},
+		{"A", 1, false},
+		{"1", 1, false},
+		{"+", 1, false},
+		{"2", 2, true},
+		{"b", 3, false},
+		{"c", 5, false},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Literal != tt.expectedLiteral || tok.Line != tt.expectedLine || tok.Continued != tt.expectedContinued {
+			t.Fatalf("tests[%d] - expected %q on line %d (continued=%t), got %q on line %d (continued=%t)",
+				i, tt.expectedLiteral, tt.expectedLine, tt.expectedContinued, tok.Literal, tok.Line, tok.Continued)
+		}
+	}
+}
+
+func TestConditionalDirectiveTokens(t *testing.T) {
+	input := "#if ~A >>> 1 ? 1 : 0\n#elseif 1\n#else\n#ifndef B\n#endif"
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.HASH_IF, "#if"},
+		{token.TILDE, "~"},
+		{token.IDENT, "A"},
+		{token.SHRU, ">>>"},
+		{token.INT, "1"},
+		{token.QUESTION, "?"},
+		{token.INT, "1"},
+		{token.COLON, ":"},
+		{token.INT, "0"},
+		{token.ELSEIF, "#elseif"},
+		{token.INT, "1"},
+		{token.HASH_ELSE, "#else"},
+		{token.IFNDEF, "#ifndef"},
+		{token.IDENT, "B"},
+		{token.ENDIF, "#endif"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType || tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] wrong. expected=%q %q, got=%q %q",
+				i, tt.expectedType, tt.expectedLiteral, tok.Type, tok.Literal)
+		}
+	}
+}
+
+func TestQuotedLiterals(t *testing.T) {
+	input := "\"say \\\"hi\\\"\" '\\'' #error Don't\nnew \"open"
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.STRING, `say \"hi\"`},
+		{token.CHAR, `'\''`},
+		{token.ERROR, "#error"},
+		{token.IDENT, "Don"},
+		{token.CHAR, "'t"}, // unterminated: ends with the line
+		{token.NEW, "new"},
+		{token.STRING, "open"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType || tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] wrong. expected=%q %q, got=%q %q",
+				i, tt.expectedType, tt.expectedLiteral, tok.Type, tok.Literal)
+		}
+	}
+}
@@ -1,36 +1,170 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"io/ioutil"
 	"os"
+	"strings"
 
 	"github.com/Tramposo1312/pawn-parser/ast"
 	"github.com/Tramposo1312/pawn-parser/lexer"
 	"github.com/Tramposo1312/pawn-parser/parser"
+	"github.com/Tramposo1312/pawn-parser/preprocessor"
+	"github.com/Tramposo1312/pawn-parser/symbol"
 )
 
+// pathList collects a flag that may be given more than once.
+type pathList []string
+
+func (pl *pathList) String() string {
+	return strings.Join(*pl, string(os.PathListSeparator))
+}
+
+func (pl *pathList) Set(value string) error {
+	*pl = append(*pl, value)
+	return nil
+}
+
+// definitions collects -D flags into a preprocessor configuration.
+type definitions struct {
+	config *preprocessor.Config
+	given  []string
+}
+
+func (d *definitions) String() string {
+	return strings.Join(d.given, " ")
+}
+
+func (d *definitions) Set(value string) error {
+	d.given = append(d.given, value)
+	return d.config.Define(value)
+}
+
+// takePositional defines the arguments written pawncc style as
+// "NAME=value" and returns the others.
+func (d *definitions) takePositional(args []string) ([]string, error) {
+	rest := []string{}
+	for _, arg := range args {
+		if !strings.Contains(arg, "=") {
+			rest = append(rest, arg)
+			continue
+		}
+		if err := d.Set(arg); err != nil {
+			return nil, err
+		}
+	}
+	return rest, nil
+}
+
+// printWarnings writes warnings to stderr under a heading naming the stage
+// that gave them.
+func printWarnings(stage string, warnings []string) {
+	if len(warnings) > 0 {
+		fmt.Fprintf(os.Stderr, "%s warnings:\n%s\n", stage, strings.Join(warnings, "\n"))
+	}
+}
+
+// dialects are the names the -dialect flag accepts.
+var dialects = map[string]parser.Dialect{
+	"ysi":    parser.YSI,
+	"openmp": parser.OpenMP,
+	"samp":   parser.SAMP,
+}
+
 func main() {
-	if len(os.Args) < 2 {
-		fmt.Println("Usage: go run main.go <filename.pwn>")
-		os.Exit(1)
+	var config preprocessor.Config
+	var includeDirs pathList
+	defines := &definitions{config: &config}
+	flag.Var(&includeDirs, "i", "include directory searched for #include files with -p or -E (may be repeated)")
+	flag.Var(defines, "D", "define a constant for -p or -E, as NAME or NAME=value (may be repeated)")
+	preprocess := flag.Bool("p", false, "preprocess before parsing; without it the file is parsed as written and directives stay in the tree")
+	expand := flag.Bool("E", false, "write the preprocessed source instead of parsing it")
+	lineMarkers := flag.Bool("markers", false, "with -E, write #file and #line markers giving the original positions")
+	dialectName := flag.String("dialect", "ysi", "language to parse: samp (Pawn 3.2), openmp (Pawn 3.10) or ysi (open.mp with YSI)")
+	flag.Usage = func() {
+		fmt.Println("Usage: go run main.go [-p | -E [-markers]] [-dialect name] [-i dir]... [-D NAME[=value]]... [NAME=value]... <filename.pwn>")
+		flag.PrintDefaults()
 	}
+	flag.Parse()
 
-	filename := os.Args[1]
-	content, err := ioutil.ReadFile(filename)
+	files, err := defines.takePositional(flag.Args())
 	if err != nil {
-		fmt.Printf("Error reading file: %v\n", err)
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if len(files) != 1 {
+		if len(files) > 1 {
+			fmt.Printf("Expected one source file, got %d: %s\n", len(files), strings.Join(files, " "))
+		}
+		flag.Usage()
 		os.Exit(1)
 	}
+	filename := files[0]
+	dialect, ok := dialects[*dialectName]
+	if !ok {
+		fmt.Printf("Unknown dialect %q: expected samp, openmp or ysi\n", *dialectName)
+		os.Exit(1)
+	}
+	config.Version = dialect.PawnVersion()
 
-	l := lexer.New(string(content))
-	p := parser.New(l)
+	if len(includeDirs) > 0 {
+		config.Resolver = preprocessor.NewResolver(includeDirs...)
+	}
+
+	if *expand {
+		pp, err := preprocessor.Open(filename, config)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+			os.Exit(1)
+		}
+		if err := pp.WriteExpanded(os.Stdout, *lineMarkers); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
+			os.Exit(1)
+		}
+		printWarnings("Preprocessor", pp.Warnings())
+		if len(pp.Errors()) > 0 {
+			fmt.Fprintf(os.Stderr, "Preprocessor errors:\n%s\n", strings.Join(pp.Errors(), "\n"))
+			os.Exit(1)
+		}
+		return
+	}
+
+	var source parser.TokenSource
+	var pp *preprocessor.Preprocessor
+	if *preprocess {
+		var err error
+		pp, err = preprocessor.Open(filename, config)
+		if err != nil {
+			fmt.Printf("Error reading file: %v\n", err)
+			os.Exit(1)
+		}
+		source = pp
+	} else {
+		content, err := ioutil.ReadFile(filename)
+		if err != nil {
+			fmt.Printf("Error reading file: %v\n", err)
+			os.Exit(1)
+		}
+		source = lexer.NewFile(filename, string(content))
+	}
+
+	p := parser.NewWithOptions(source, parser.Options{Dialect: dialect})
 
 	program, err := p.ParseProgram()
+	if pp != nil {
+		printWarnings("Preprocessor", pp.Warnings())
+	}
 	if err != nil {
 		fmt.Printf("Parser errors:\n%v\n", err)
 		os.Exit(1)
 	}
+	if pp != nil && len(pp.Errors()) > 0 {
+		fmt.Printf("Preprocessor errors:\n%s\n", strings.Join(pp.Errors(), "\n"))
+		os.Exit(1)
+	}
+
+	printWarnings("Symbol", symbol.Build(program).Warnings())
 
 	fmt.Println("Parsing completed successfully.")
 	printer := ast.NewAstPrinter()
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Tramposo1312/pawn-parser/preprocessor"
+)
+
+func TestPositionalDefinitions(t *testing.T) {
+	var config preprocessor.Config
+	defines := &definitions{config: &config}
+
+	files, err := defines.takePositional([]string{"MAX_PLAYERS=50", "script.pwn", "DEBUG="})
+	if err != nil {
+		t.Fatalf("takePositional() failed: %s", err)
+	}
+	if !reflect.DeepEqual(files, []string{"script.pwn"}) {
+		t.Errorf("files wrong. got=%q", files)
+	}
+	if config.Symbols["MAX_PLAYERS"] != 50 || config.Symbols["DEBUG"] != 0 {
+		t.Errorf("symbols wrong. got=%v", config.Symbols)
+	}
+	if len(defines.given) != 2 {
+		t.Errorf("expected 2 definitions. got=%q", defines.given)
+	}
+
+	if _, err := defines.takePositional([]string{"1X=2", "script.pwn"}); err == nil {
+		t.Errorf("expected an error for an invalid definition")
+	}
+}
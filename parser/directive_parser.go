@@ -2,32 +2,42 @@ package parser
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/Tramposo1312/pawn-parser/ast"
-	"github.com/Tramposo1312/pawn-parser/precedence"
+	"github.com/Tramposo1312/pawn-parser/constexpr"
 	"github.com/Tramposo1312/pawn-parser/token"
 )
 
+// parseIncludeDirective parses "#include <name>", "#include "name"" and
+// the same forms of #tryinclude. Like pawncc, a name without delimiters is
+// looked up the way a <name> is.
 func (p *Parser) parseIncludeDirective() (*ast.IncludeDirective, error) {
-	directive := &ast.IncludeDirective{Token: p.curToken}
-
-	if !p.expectPeek(token.LT) && !p.expectPeek(token.STRING) {
-		return nil, fmt.Errorf("expected < or \" after #include, got %s", p.peekToken.Type)
+	directive := &ast.IncludeDirective{
+		Token:    p.curToken,
+		Optional: p.curTokenIs(token.TRYINCLUDE),
 	}
 
-	if p.curTokenIs(token.LT) {
-		p.nextToken() // consume
-		var path string
-		for !p.curTokenIs(token.GT) && !p.curTokenIs(token.EOF) {
-			path += p.curToken.Literal
-			p.nextToken()
-		}
-		if !p.curTokenIs(token.GT) {
+	text := tokensText(p.readDirectiveLine())
+	switch {
+	case text == "":
+		return nil, fmt.Errorf("expected a file name after %s", directive.Token.Literal)
+	case text[0] == '<':
+		end := strings.IndexByte(text, '>')
+		if end < 0 {
 			return nil, fmt.Errorf("expected > to close include path")
 		}
-		directive.Path = path
-	} else {
-		directive.Path = p.curToken.Literal
+		directive.Path = strings.TrimSpace(text[1:end])
+		directive.System = true
+	case text[0] == '"':
+		end := strings.IndexByte(text[1:], '"')
+		if end < 0 {
+			return nil, fmt.Errorf("expected \" to close include path")
+		}
+		directive.Path = text[1 : end+1]
+	default:
+		directive.Path = strings.Fields(text)[0]
+		directive.System = true
 	}
 
 	return directive, nil
@@ -36,190 +46,400 @@ func (p *Parser) parseIncludeDirective() (*ast.IncludeDirective, error) {
 func (p *Parser) parseDefineDirective() (*ast.DefineDirective, error) {
 	directive := &ast.DefineDirective{Token: p.curToken}
 
-	if !p.expectPeek(token.IDENT) {
+	// The pattern runs to the first whitespace; whatever follows on the
+	// line is the substitution.
+	if !p.peekOnLine() || !p.peekTokenIs(token.IDENT) {
 		return nil, fmt.Errorf("expected identifier after #define, got %s", p.peekToken.Type)
 	}
-
-	directive.Name = p.curToken.Literal
-
 	p.nextToken()
-	value, err := p.parseExpression(precedence.LOWEST)
-	if err != nil {
-		return nil, err
+	pattern := []token.Token{p.curToken}
+	for p.peekOnLine() && !p.peekToken.Continued && p.peekToken.Column == p.curToken.End() {
+		p.nextToken()
+		pattern = append(pattern, p.curToken)
 	}
 
-	directive.Value = value
+	directive.Pattern = tokensText(pattern)
+	// The lexer reads a name and a following ':' as one identifier.
+	directive.Name = pattern[0].Literal
+	if i := strings.IndexByte(directive.Name, ':'); i >= 0 {
+		directive.Name = directive.Name[:i]
+	}
+	directive.Body = p.readDirectiveLine()
+	directive.Substitution = tokensText(directive.Body)
 	return directive, nil
 }
 
-func (p *Parser) parseIfDefDirective() (*ast.IfDefDirective, error) {
-	directive := &ast.IfDefDirective{Token: p.curToken}
+// parseConditionalDirective parses an #if, #ifdef or #ifndef block up to
+// its #endif, keeping every branch. Conditions are not evaluated here;
+// that is the preprocessor's job.
+func (p *Parser) parseConditionalDirective() (*ast.ConditionalDirective, error) {
+	directive := &ast.ConditionalDirective{Token: p.curToken}
+
+	seenElse := false
+	for {
+		branch := &ast.ConditionalBranch{Token: p.curToken}
+		isElse := p.curTokenIs(token.HASH_ELSE)
+
+		branch.ConditionTokens = p.readDirectiveLine()
+		branch.Condition = tokensText(branch.ConditionTokens)
+		switch {
+		case isElse && branch.Condition != "":
+			return nil, fmt.Errorf("unexpected %q after #else", branch.Condition)
+		case !isElse && branch.Condition == "":
+			return nil, fmt.Errorf("expected a condition after %s", branch.Token.Literal)
+		}
+		p.nextToken()
 
-	if !p.expectPeek(token.IDENT) {
-		return nil, fmt.Errorf("expected identifier after #ifdef, got %s", p.peekToken.Type)
+		for !p.curTokenIs(token.ELSEIF) && !p.curTokenIs(token.HASH_ELSE) &&
+			!p.curTokenIs(token.ENDIF) && !p.curTokenIs(token.EOF) {
+			stmt, err := p.parseStatement()
+			if err != nil {
+				return nil, err
+			}
+			if stmt != nil {
+				branch.Body = append(branch.Body, stmt)
+			}
+			p.nextToken()
+		}
+		directive.Branches = append(directive.Branches, branch)
+		seenElse = seenElse || isElse
+
+		switch {
+		case p.curTokenIs(token.EOF):
+			return nil, fmt.Errorf("expected #endif for %s on line %d", directive.Token.Literal, directive.Token.Line)
+		case p.curTokenIs(token.ENDIF):
+			directive.End = p.curToken
+			return directive, nil
+		case seenElse:
+			return nil, fmt.Errorf("%s after #else on line %d", p.curToken.Literal, p.curToken.Line)
+		}
 	}
+}
 
-	directive.Condition = p.curToken.Literal
+// parseEmitDirective parses an "#emit opcode operands" line. The
+// instruction ends with the line.
+func (p *Parser) parseEmitDirective() (*ast.EmitDirective, error) {
+	directive := &ast.EmitDirective{Token: p.curToken}
+	start := p.curToken
+	sameLine := p.peekOnLine
 
-	if !p.expectPeek(token.LBRACE) {
-		return nil, fmt.Errorf("expected { after #ifdef condition")
+	if !sameLine() {
+		return nil, fmt.Errorf("expected opcode after #emit on line %d", start.Line)
 	}
+	p.nextToken()
 
-	body, err := p.parseBlockStatement()
+	instruction, err := p.parseEmitInstruction(sameLine)
 	if err != nil {
 		return nil, err
 	}
-	directive.Body = body.Statements
+	directive.Instruction = instruction
+
+	return directive, nil
+}
+
+// parseEmitInstruction parses an opcode such as "load.s.pri" and its
+// operands, validating both against the AMX opcode list. more reports
+// whether the next token still belongs to this instruction.
+func (p *Parser) parseEmitInstruction(more func() bool) (*ast.EmitInstruction, error) {
+	instruction := &ast.EmitInstruction{Token: p.curToken}
+
+	if !p.curToken.IsWord() {
+		return nil, fmt.Errorf("expected opcode, got %s", p.curToken.Type)
+	}
 
-	if p.peekTokenIs(token.ELSE) {
-		p.nextToken() // consume else
-		if !p.expectPeek(token.LBRACE) {
-			return nil, fmt.Errorf("expected { after else")
+	opcode := p.curToken.Literal
+	for p.peekTokenIs(token.PERIOD) && more() {
+		p.nextToken()
+		if !more() || !p.peekToken.IsWord() && !p.peekTokenIs(token.INT) {
+			return nil, fmt.Errorf("malformed opcode %q", opcode+".")
 		}
-		elseBody, err := p.parseBlockStatement()
+		p.nextToken()
+		opcode += "." + p.curToken.Literal
+	}
+	instruction.Opcode = strings.ToLower(opcode)
+
+	kind, ok := amxOpcodes[instruction.Opcode]
+	if !ok {
+		return nil, fmt.Errorf("unknown AMX opcode %q", opcode)
+	}
+
+	for more() {
+		p.nextToken()
+		operand, err := p.parseEmitOperand(kind)
 		if err != nil {
 			return nil, err
 		}
-		directive.ElseBody = elseBody.Statements
+		instruction.Operands = append(instruction.Operands, operand)
 	}
 
-	if !p.expectPeek(token.ENDIF) {
-		return nil, fmt.Errorf("expected #endif, got %s", p.peekToken.Type)
+	if len(instruction.Operands) != kind.operandCount() {
+		return nil, fmt.Errorf("opcode %s expects %d operand(s), got %d",
+			instruction.Opcode, kind.operandCount(), len(instruction.Operands))
 	}
 
-	return directive, nil
+	return instruction, nil
 }
 
-func (p *Parser) parseNativeFunctionDeclaration() (*ast.NativeFunctionDeclaration, error) {
-	decl := &ast.NativeFunctionDeclaration{Token: p.curToken}
+func (p *Parser) parseEmitOperand(kind emitOperand) (*ast.EmitOperand, error) {
+	operand := &ast.EmitOperand{Token: p.curToken}
 
-	if !p.expectPeek(token.IDENT) {
-		return nil, fmt.Errorf("expected function name after 'native', got %s", p.peekToken.Type)
+	switch {
+	case p.curTokenIs(token.MINUS) && p.peekTokenIs(token.INT):
+		p.nextToken()
+		operand.Kind = ast.EmitNumber
+		operand.Value = "-" + p.curToken.Literal
+	case p.curTokenIs(token.INT), p.curTokenIs(token.CHAR):
+		operand.Kind = ast.EmitNumber
+		operand.Value = p.curToken.Literal
+	case p.curToken.IsWord():
+		operand.Kind = ast.EmitSymbol
+		if kind == emitTarget {
+			operand.Kind = ast.EmitLabel
+		}
+		operand.Value = p.curToken.Literal
+	default:
+		return nil, fmt.Errorf("invalid operand %q", p.curToken.Literal)
 	}
 
-	decl.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	return operand, nil
+}
 
-	if !p.expectPeek(token.LPAREN) {
-		return nil, fmt.Errorf("expected ( after function name, got %s", p.peekToken.Type)
-	}
+// parsePragmaDirective parses a "#pragma" line. Unknown pragmas are kept
+// with their raw arguments; the common ones are decoded and
+// "#pragma semicolon" switches strict semicolon checking on or off.
+func (p *Parser) parsePragmaDirective() (*ast.PragmaDirective, error) {
+	directive := &ast.PragmaDirective{Token: p.curToken}
 
-	params, err := p.parseFunctionParameters()
-	if err != nil {
+	args := p.readDirectiveLine()
+	if len(args) == 0 || !args[0].IsWord() {
+		return nil, fmt.Errorf("expected pragma name after #pragma on line %d", directive.Token.Line)
+	}
+	if err := decodePragma(directive, args); err != nil {
 		return nil, err
 	}
-	decl.Parameters = params
-
-	if p.peekTokenIs(token.COLON) {
-		p.nextToken() // consume :
-		p.nextToken() // move to return type
-		returnType, err := p.parseExpression(precedence.LOWEST)
-		if err != nil {
-			return nil, err
-		}
-		decl.ReturnType = returnType
+	if directive.Name == "semicolon" {
+		p.requireSemicolons = directive.Value != 0
 	}
+	return directive, nil
+}
 
-	if !p.expectPeek(token.SEMICOLON) {
-		return nil, fmt.Errorf("expected ; after native function declaration")
+// decodePragma fills in directive from the tokens of a pragma line, the
+// first of which is the pragma's name. It only decodes; what a pragma does
+// to the parser is left to the caller.
+func decodePragma(directive *ast.PragmaDirective, args []token.Token) error {
+	directive.Name = args[0].Literal
+	args = args[1:]
+	directive.Arguments = tokensText(args)
+
+	switch directive.Name {
+	case "unused":
+		for _, arg := range args {
+			if arg.Type != token.COMMA {
+				directive.Symbols = append(directive.Symbols, arg.Literal)
+			}
+		}
+	case "deprecated":
+		directive.Message = directive.Arguments
+	case "dynamic", "tabsize", "semicolon", "ctrlchar":
+		value, err := pragmaNumber(args)
+		switch {
+		case err == nil:
+			directive.Value = value
+		case directive.Name != "semicolon" && hasName(args):
+			// A constant declared in the script, as in "#pragma dynamic
+			// MAX_SIZE * 4", cannot be evaluated here; the raw
+			// Arguments are kept instead.
+		default:
+			return fmt.Errorf("invalid #pragma %s: %v", directive.Name, err)
+		}
+	case "option":
+		directive.Options = strings.Fields(directive.Arguments)
+		if len(directive.Options) == 0 {
+			return fmt.Errorf("#pragma option expects compiler options")
+		}
+	case "warning":
+		if err := parsePragmaWarning(directive, args); err != nil {
+			return fmt.Errorf("invalid #pragma warning: %v", err)
+		}
+	case "rational":
+		if len(args) == 0 {
+			return fmt.Errorf("invalid #pragma rational: expected a tag name")
+		}
+		directive.Symbols = []string{args[0].Literal}
+		if len(args) == 4 && args[1].Type == token.LPAREN && args[3].Type == token.RPAREN {
+			value, err := pragmaNumber(args[2:3])
+			if err != nil {
+				return fmt.Errorf("invalid #pragma rational: %v", err)
+			}
+			directive.Value = value
+		}
+	case "naked":
+		if len(args) != 0 {
+			return fmt.Errorf("#pragma naked takes no arguments")
+		}
 	}
 
-	return decl, nil
+	return nil
 }
-func (p *Parser) parseFunctionDeclaration() (*ast.FunctionDeclaration, error) {
-	decl := &ast.FunctionDeclaration{Token: p.curToken}
 
-	if !p.expectPeek(token.IDENT) {
-		return nil, fmt.Errorf("expected function name, got %s", p.peekToken.Type)
+func parsePragmaWarning(directive *ast.PragmaDirective, args []token.Token) error {
+	if len(args) == 0 {
+		return fmt.Errorf("expected push, pop, enable or disable")
 	}
 
-	decl.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
-
-	if !p.expectPeek(token.LPAREN) {
-		return nil, fmt.Errorf("expected ( after function name, got %s", p.peekToken.Type)
+	directive.Action = args[0].Literal
+	switch directive.Action {
+	case "push", "pop":
+		if len(args) > 1 {
+			return fmt.Errorf("%s takes no warning numbers", directive.Action)
+		}
+	case "enable", "disable":
+		for _, arg := range args[1:] {
+			if arg.Type == token.COMMA {
+				continue
+			}
+			value, err := pragmaNumber([]token.Token{arg})
+			if err != nil {
+				return err
+			}
+			directive.Warnings = append(directive.Warnings, value)
+		}
+		if len(directive.Warnings) == 0 {
+			return fmt.Errorf("%s needs at least one warning number", directive.Action)
+		}
+	default:
+		return fmt.Errorf("unknown action %q", directive.Action)
 	}
 
-	params, err := p.parseFunctionParameters()
-	if err != nil {
-		return nil, err
+	return nil
+}
+
+// pragmaNumber evaluates a pragma argument as a constant expression, such
+// as "4 * 1024". Constants declared in the script cannot be resolved here,
+// so an argument that names one gives an error.
+func pragmaNumber(args []token.Token) (int64, error) {
+	if len(args) == 0 {
+		return 0, fmt.Errorf("expected a number")
 	}
-	decl.Parameters = params
+	return constexpr.Eval(args, nil)
+}
 
-	if !p.expectPeek(token.LBRACE) {
-		return nil, fmt.Errorf("expected { to start function body, got %s", p.peekToken.Type)
+// hasName reports whether args refer to a constant by name.
+func hasName(args []token.Token) bool {
+	for _, arg := range args {
+		if arg.Type == token.IDENT {
+			return true
+		}
 	}
+	return false
+}
 
-	body, err := p.parseBlockStatement()
-	if err != nil {
-		return nil, err
+func (p *Parser) parseUndefDirective() (*ast.UndefDirective, error) {
+	directive := &ast.UndefDirective{Token: p.curToken}
+	args := p.readDirectiveLine()
+	if len(args) != 1 {
+		return nil, fmt.Errorf("expected a macro name after #undef on line %d", directive.Token.Line)
 	}
-	decl.Body = body
+	directive.Name = args[0].Literal
+	return directive, nil
+}
 
-	return decl, nil
+func (p *Parser) parseErrorDirective() (*ast.ErrorDirective, error) {
+	directive := &ast.ErrorDirective{Token: p.curToken}
+	directive.Message = tokensText(p.readDirectiveLine())
+	return directive, nil
 }
-func (p *Parser) parseStateDeclaration() (*ast.StateDeclaration, error) {
-	decl := &ast.StateDeclaration{Token: p.curToken}
 
-	// the state name
-	if !p.expectPeek(token.IDENT) {
-		return nil, fmt.Errorf("expected state name after 'state', got %s", p.peekToken.Type)
-	}
-	decl.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+func (p *Parser) parseWarningDirective() (*ast.WarningDirective, error) {
+	directive := &ast.WarningDirective{Token: p.curToken}
+	directive.Message = tokensText(p.readDirectiveLine())
+	return directive, nil
+}
 
-	// opening brace
-	if !p.expectPeek(token.LBRACE) {
-		return nil, fmt.Errorf("expected '{' after state name, got %s", p.peekToken.Type)
+func (p *Parser) parseAssertDirective() (*ast.AssertDirective, error) {
+	directive := &ast.AssertDirective{Token: p.curToken}
+	directive.ConditionTokens = p.readDirectiveLine()
+	if len(directive.ConditionTokens) == 0 {
+		return nil, fmt.Errorf("expected an expression after #assert on line %d", directive.Token.Line)
 	}
+	directive.Condition = tokensText(directive.ConditionTokens)
+	return directive, nil
+}
 
-	body, err := p.parseBlockStatement()
+func (p *Parser) parseLineDirective() (*ast.LineDirective, error) {
+	directive := &ast.LineDirective{Token: p.curToken}
+	line, err := constexpr.Eval(p.readDirectiveLine(), nil)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("invalid #line number on line %d: %v", directive.Token.Line, err)
 	}
-	decl.Body = body
-
-	return decl, nil
+	directive.Line = line
+	return directive, nil
 }
-func (p *Parser) parseFunctionParameters() ([]*ast.Identifier, error) {
-	identifiers := []*ast.Identifier{}
 
-	if p.peekTokenIs(token.RPAREN) {
-		p.nextToken()
-		return identifiers, nil
+func (p *Parser) parseFileDirective() (*ast.FileDirective, error) {
+	directive := &ast.FileDirective{Token: p.curToken}
+	args := p.readDirectiveLine()
+	switch {
+	case len(args) == 0:
+		return nil, fmt.Errorf("expected a file name after #file on line %d", directive.Token.Line)
+	case len(args) == 1 && args[0].Type == token.STRING:
+		directive.Name = args[0].Literal
+	default:
+		directive.Name = tokensText(args)
 	}
+	return directive, nil
+}
 
-	p.nextToken()
+func (p *Parser) parseSectionDirective() (*ast.SectionDirective, error) {
+	directive := &ast.SectionDirective{Token: p.curToken}
+	directive.Name = tokensText(p.readDirectiveLine())
+	return directive, nil
+}
 
-	ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
-	if p.peekTokenIs(token.COLON) {
+// readDirectiveLine consumes the tokens that follow the current directive
+// token on the same logical line.
+func (p *Parser) readDirectiveLine() []token.Token {
+	tokens := []token.Token{}
+	for p.peekOnLine() {
 		p.nextToken()
-		ident.Value += ":" + p.curToken.Literal
+		tokens = append(tokens, p.curToken)
 	}
-	identifiers = append(identifiers, ident)
+	return tokens
+}
 
-	for p.peekTokenIs(token.COMMA) {
-		p.nextToken()
-		p.nextToken()
-		ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
-		if p.peekTokenIs(token.COLON) {
-			p.nextToken()
-			ident.Value += ":" + p.curToken.Literal
-		}
-		identifiers = append(identifiers, ident)
+// peekOnLine reports whether peekToken is on the same logical line as
+// curToken: either on the same source line or joined to it by a trailing
+// backslash. Tokens from different files may share line numbers once
+// includes are expanded, so the file has to match too.
+func (p *Parser) peekOnLine() bool {
+	if p.peekTokenIs(token.EOF) || p.peekToken.File != p.curToken.File {
+		return false
 	}
+	return p.peekToken.Line == p.curToken.Line || p.peekToken.Continued
+}
 
-	if !p.expectPeek(token.RPAREN) {
-		return nil, fmt.Errorf("expected ')' after function parameters")
+// tokensText rebuilds the source text of tokens from a single logical
+// line, keeping a space wherever the original had whitespace or a line
+// continuation.
+func tokensText(tokens []token.Token) string {
+	var out strings.Builder
+	for i, tok := range tokens {
+		if i > 0 && (tok.Continued || tok.Column > tokens[i-1].End()) {
+			out.WriteString(" ")
+		}
+		out.WriteString(tok.Text())
 	}
-
-	return identifiers, nil
+	return out.String()
 }
+
 func (p *Parser) parseDirective() (ast.Statement, error) {
 	switch p.peekToken.Literal {
 	case "include":
 		return p.parseIncludeDirective()
 	case "define":
 		return p.parseDefineDirective()
-	case "ifdef":
-		return p.parseIfDefDirective()
+	case "if", "ifdef", "ifndef":
+		return p.parseConditionalDirective()
 	default:
 		return nil, fmt.Errorf("unknown directive: %s", p.peekToken.Literal)
 	}
@@ -0,0 +1,164 @@
+package parser
+
+// emitOperand describes what an AMX opcode expects after it in "#emit" and
+// "__emit" inline assembly.
+type emitOperand int
+
+const (
+	emitNone   emitOperand = iota // no operand
+	emitValue                     // a number or symbol
+	emitTarget                    // a jump label
+	emitPair                      // two numbers or symbols
+)
+
+// amxOpcodes lists the opcodes accepted by the compiler's inline assembler,
+// keyed by their lower case mnemonic.
+var amxOpcodes = map[string]emitOperand{
+	"load.pri":    emitValue,
+	"load.alt":    emitValue,
+	"load.s.pri":  emitValue,
+	"load.s.alt":  emitValue,
+	"lref.pri":    emitValue,
+	"lref.alt":    emitValue,
+	"lref.s.pri":  emitValue,
+	"lref.s.alt":  emitValue,
+	"load.i":      emitNone,
+	"lodb.i":      emitValue,
+	"const.pri":   emitValue,
+	"const.alt":   emitValue,
+	"addr.pri":    emitValue,
+	"addr.alt":    emitValue,
+	"stor.pri":    emitValue,
+	"stor.alt":    emitValue,
+	"stor.s.pri":  emitValue,
+	"stor.s.alt":  emitValue,
+	"sref.pri":    emitValue,
+	"sref.alt":    emitValue,
+	"sref.s.pri":  emitValue,
+	"sref.s.alt":  emitValue,
+	"stor.i":      emitNone,
+	"strb.i":      emitValue,
+	"lidx":        emitNone,
+	"lidx.b":      emitValue,
+	"idxaddr":     emitNone,
+	"idxaddr.b":   emitValue,
+	"align.pri":   emitValue,
+	"align.alt":   emitValue,
+	"lctrl":       emitValue,
+	"sctrl":       emitValue,
+	"move.pri":    emitNone,
+	"move.alt":    emitNone,
+	"xchg":        emitNone,
+	"push.pri":    emitNone,
+	"push.alt":    emitNone,
+	"push.r":      emitValue,
+	"push.c":      emitValue,
+	"push":        emitValue,
+	"push.s":      emitValue,
+	"push.adr":    emitValue,
+	"pop.pri":     emitNone,
+	"pop.alt":     emitNone,
+	"stack":       emitValue,
+	"heap":        emitValue,
+	"proc":        emitNone,
+	"ret":         emitNone,
+	"retn":        emitNone,
+	"call":        emitValue,
+	"call.pri":    emitNone,
+	"jump":        emitTarget,
+	"jump.pri":    emitNone,
+	"jrel":        emitValue,
+	"jzer":        emitTarget,
+	"jnz":         emitTarget,
+	"jeq":         emitTarget,
+	"jneq":        emitTarget,
+	"jless":       emitTarget,
+	"jleq":        emitTarget,
+	"jgrtr":       emitTarget,
+	"jgeq":        emitTarget,
+	"jsless":      emitTarget,
+	"jsleq":       emitTarget,
+	"jsgrtr":      emitTarget,
+	"jsgeq":       emitTarget,
+	"shl":         emitNone,
+	"shr":         emitNone,
+	"sshr":        emitNone,
+	"shl.c.pri":   emitValue,
+	"shl.c.alt":   emitValue,
+	"shr.c.pri":   emitValue,
+	"shr.c.alt":   emitValue,
+	"smul":        emitNone,
+	"sdiv":        emitNone,
+	"sdiv.alt":    emitNone,
+	"umul":        emitNone,
+	"udiv":        emitNone,
+	"udiv.alt":    emitNone,
+	"add":         emitNone,
+	"sub":         emitNone,
+	"sub.alt":     emitNone,
+	"and":         emitNone,
+	"or":          emitNone,
+	"xor":         emitNone,
+	"not":         emitNone,
+	"neg":         emitNone,
+	"invert":      emitNone,
+	"add.c":       emitValue,
+	"smul.c":      emitValue,
+	"zero.pri":    emitNone,
+	"zero.alt":    emitNone,
+	"zero":        emitValue,
+	"zero.s":      emitValue,
+	"sign.pri":    emitNone,
+	"sign.alt":    emitNone,
+	"eq":          emitNone,
+	"neq":         emitNone,
+	"less":        emitNone,
+	"leq":         emitNone,
+	"grtr":        emitNone,
+	"geq":         emitNone,
+	"sless":       emitNone,
+	"sleq":        emitNone,
+	"sgrtr":       emitNone,
+	"sgeq":        emitNone,
+	"eq.c.pri":    emitValue,
+	"eq.c.alt":    emitValue,
+	"inc.pri":     emitNone,
+	"inc.alt":     emitNone,
+	"inc":         emitValue,
+	"inc.s":       emitValue,
+	"inc.i":       emitNone,
+	"dec.pri":     emitNone,
+	"dec.alt":     emitNone,
+	"dec":         emitValue,
+	"dec.s":       emitValue,
+	"dec.i":       emitNone,
+	"movs":        emitValue,
+	"cmps":        emitValue,
+	"fill":        emitValue,
+	"halt":        emitValue,
+	"bounds":      emitValue,
+	"sysreq.pri":  emitNone,
+	"sysreq.c":    emitValue,
+	"sysreq.n":    emitPair,
+	"switch":      emitTarget,
+	"swap.pri":    emitNone,
+	"swap.alt":    emitNone,
+	"nop":         emitNone,
+	"break":       emitNone,
+	"load.both":   emitPair,
+	"load.s.both": emitPair,
+	"const":       emitPair,
+	"const.s":     emitPair,
+}
+
+// operandCount returns how many operands an opcode of this kind takes.
+func (e emitOperand) operandCount() int {
+	switch e {
+	case emitNone:
+		return 0
+	case emitPair:
+		return 2
+	default:
+		return 1
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/Tramposo1312/pawn-parser/ast"
+	"github.com/Tramposo1312/pawn-parser/lexer"
 	"github.com/Tramposo1312/pawn-parser/precedence"
 	"github.com/Tramposo1312/pawn-parser/token"
 )
@@ -14,6 +15,43 @@ type (
 )
 
 func (p *Parser) parseIdentifier() (ast.Expression, error) {
+	// The open.mp compiler's operators.
+	if p.dialect != SAMP && p.peekTokenIs(token.LPAREN) {
+		switch p.curToken.Literal {
+		case "__emit":
+			return p.parseEmitExpression()
+		case "__nameof", "__addressof":
+			return p.parseSymbolOperatorExpression()
+		case "__static_assert", "__static_check":
+			return p.parseStaticAssertExpression()
+		case "__pragma":
+			return p.parsePragmaExpression()
+		}
+	}
+	if p.dialect != YSI {
+		return &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}, nil
+	}
+	// y_timers' defer, repeat and stop and y_inline's using are macros
+	// rather than keywords, so they are only recognised when a name
+	// follows.
+	if p.peekTokenIs(token.IDENT) {
+		switch p.curToken.Literal {
+		case "defer", "repeat":
+			return p.parseTimerCallExpression()
+		case "stop":
+			return p.parseStopExpression()
+		}
+	}
+	if p.curToken.Literal == "using" && (p.peekTokenIs(token.IDENT) || p.peekTokenIs(token.PUBLIC)) {
+		p.curToken.Type = token.USING
+		return p.parseUsingExpression()
+	}
+	if p.curToken.Literal == "___" {
+		return p.parseVarArgsExpression()
+	}
+	if len(p.curToken.Literal) > 1 && p.curToken.Literal[0] == '@' {
+		return &ast.SpecialIdentifier{Token: p.curToken, Name: p.curToken.Literal[1:]}, nil
+	}
 	return &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}, nil
 }
 
@@ -63,6 +101,22 @@ func (p *Parser) parseCallExpression(function ast.Expression) (ast.Expression, e
 	return exp, nil
 }
 
+func (p *Parser) parseIndexExpression(left ast.Expression) (ast.Expression, error) {
+	exp := &ast.IndexExpression{Token: p.curToken, Left: left}
+
+	p.nextToken()
+	index, err := p.parseExpression(precedence.LOWEST)
+	if err != nil {
+		return nil, err
+	}
+	exp.Index = index
+
+	if !p.expectPeek(token.RBRACK) {
+		return nil, fmt.Errorf("expected ] after index, got %s", p.peekToken.Type)
+	}
+	return exp, nil
+}
+
 func (p *Parser) parseExpressionList(end token.TokenType) ([]ast.Expression, error) {
 	list := []ast.Expression{}
 
@@ -136,3 +190,185 @@ func (p *Parser) parseInfixExpression(left ast.Expression) (ast.Expression, erro
 
 	return expression, nil
 }
+
+// parseAssignExpression parses '=' and the compound assignments. They are
+// right associative, so "a = b = c" groups as "a = (b = c)". For-loop
+// clauses such as "i = 0, j = 10" need them as expressions.
+func (p *Parser) parseAssignExpression(left ast.Expression) (ast.Expression, error) {
+	expression := &ast.InfixExpression{
+		Token:    p.curToken,
+		Operator: p.curToken.Literal,
+		Left:     left,
+	}
+
+	p.nextToken()
+	right, err := p.parseExpression(precedence.ASSIGN - 1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse expression after operator %s: %v", expression.Operator, err)
+	}
+	expression.Right = right
+
+	return expression, nil
+}
+
+// parsePostfixExpression parses a trailing '++' or '--', as used in for-loop
+// updates like "i++, j--".
+func (p *Parser) parsePostfixExpression(left ast.Expression) (ast.Expression, error) {
+	return &ast.PostfixExpression{
+		Token:    p.curToken,
+		Left:     left,
+		Operator: p.curToken.Literal,
+	}, nil
+}
+
+// parseExpressionSequence parses one or more comma separated expressions.
+// It is only used for expression statements and for-loop clauses; argument
+// lists keep using parseExpressionList and parenthesised expressions hold a
+// single expression.
+func (p *Parser) parseExpressionSequence() (ast.Expression, error) {
+	first, err := p.parseExpression(precedence.LOWEST)
+	if err != nil {
+		return nil, err
+	}
+
+	if !p.peekTokenIs(token.COMMA) {
+		return first, nil
+	}
+
+	seq := &ast.CommaExpression{Token: p.peekToken, Expressions: []ast.Expression{first}}
+	for p.peekTokenIs(token.COMMA) {
+		p.nextToken()
+		p.nextToken()
+		expr, err := p.parseExpression(precedence.LOWEST)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse expression after comma: %v", err)
+		}
+		seq.Expressions = append(seq.Expressions, expr)
+	}
+
+	return seq, nil
+}
+
+// parseEmitExpression parses "__emit(instruction, instruction, ...)".
+func (p *Parser) parseEmitExpression() (ast.Expression, error) {
+	exp := &ast.EmitExpression{Token: p.curToken}
+	inInstruction := func() bool {
+		return !p.peekTokenIs(token.COMMA) && !p.peekTokenIs(token.RPAREN) && !p.peekTokenIs(token.EOF)
+	}
+
+	p.nextToken() // consume '('
+	for {
+		p.nextToken()
+		instruction, err := p.parseEmitInstruction(inInstruction)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse __emit instruction: %v", err)
+		}
+		exp.Instructions = append(exp.Instructions, instruction)
+
+		if !p.peekTokenIs(token.COMMA) {
+			break
+		}
+		p.nextToken()
+	}
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil, fmt.Errorf("expected ) to close __emit, got %s", p.peekToken.Type)
+	}
+
+	return exp, nil
+}
+
+// parseSymbolOperatorExpression parses "__nameof(Symbol)" and
+// "__addressof(Function)".
+func (p *Parser) parseSymbolOperatorExpression() (ast.Expression, error) {
+	exp := &ast.SymbolOperatorExpression{Token: p.curToken}
+
+	p.nextToken() // consume '('
+	if !p.expectPeek(token.IDENT) {
+		return nil, fmt.Errorf("expected a name in %s, got %s", exp.Token.Literal, p.peekToken.Type)
+	}
+	exp.Symbol = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil, fmt.Errorf("expected ) to close %s, got %s", exp.Token.Literal, p.peekToken.Type)
+	}
+	return exp, nil
+}
+
+// parseStaticAssertExpression parses "__static_assert(cond, "message")"
+// and "__static_check(cond)"; the message is optional for both.
+func (p *Parser) parseStaticAssertExpression() (ast.Expression, error) {
+	exp := &ast.StaticAssertExpression{Token: p.curToken}
+
+	p.nextToken() // consume '('
+	p.nextToken()
+	var err error
+	exp.Condition, err = p.parseExpression(precedence.LOWEST)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s condition: %v", exp.Token.Literal, err)
+	}
+
+	if p.peekTokenIs(token.COMMA) {
+		p.nextToken()
+		p.nextToken()
+		exp.Message, err = p.parseExpression(precedence.LOWEST)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s message: %v", exp.Token.Literal, err)
+		}
+	}
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil, fmt.Errorf("expected ) to close %s, got %s", exp.Token.Literal, p.peekToken.Type)
+	}
+	return exp, nil
+}
+
+// parsePragmaExpression parses "__pragma("pragma", ...)", decoding each
+// string as the text of a #pragma line. Unlike a #pragma line it only
+// applies to its own function, so "semicolon" does not change how the
+// rest of the file is parsed.
+func (p *Parser) parsePragmaExpression() (ast.Expression, error) {
+	exp := &ast.PragmaExpression{Token: p.curToken}
+
+	p.nextToken() // consume '('
+	for {
+		if !p.expectPeek(token.STRING) {
+			return nil, fmt.Errorf("expected a pragma string in __pragma, got %s", p.peekToken.Type)
+		}
+
+		args := pragmaTokens(p.curToken)
+		if len(args) == 0 || !args[0].IsWord() {
+			return nil, fmt.Errorf("expected pragma name in __pragma on line %d, got %q", p.curToken.Line, p.curToken.Literal)
+		}
+		pragma := &ast.PragmaDirective{Token: exp.Token}
+		if err := decodePragma(pragma, args); err != nil {
+			return nil, fmt.Errorf("%s: %v", args[0].Position(), err)
+		}
+		exp.Pragmas = append(exp.Pragmas, pragma)
+
+		if !p.peekTokenIs(token.COMMA) {
+			break
+		}
+		p.nextToken()
+	}
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil, fmt.Errorf("expected ) to close __pragma, got %s", p.peekToken.Type)
+	}
+	return exp, nil
+}
+
+// pragmaTokens lexes the text of a __pragma string, placing the tokens
+// where they are in the file rather than in the string, whose text starts
+// after the opening quote.
+func pragmaTokens(str token.Token) []token.Token {
+	tokens := []token.Token{}
+	l := lexer.NewFile(str.File, str.Literal)
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		tok.Line += str.Line - 1
+		tok.Column += str.Column
+		tok.Origin = str.Origin
+		tokens = append(tokens, tok)
+	}
+	return tokens
+}
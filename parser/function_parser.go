@@ -0,0 +1,256 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Tramposo1312/pawn-parser/ast"
+	"github.com/Tramposo1312/pawn-parser/precedence"
+	"github.com/Tramposo1312/pawn-parser/token"
+)
+
+func (p *Parser) parseNativeFunctionDeclaration() (*ast.NativeFunctionDeclaration, error) {
+	decl := &ast.NativeFunctionDeclaration{Token: p.curToken}
+
+	if !p.expectPeek(token.IDENT) {
+		return nil, fmt.Errorf("expected function name after 'native', got %s", p.peekToken.Type)
+	}
+
+	decl.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil, fmt.Errorf("expected ( after function name, got %s", p.peekToken.Type)
+	}
+
+	params, err := p.parseFunctionParameters()
+	if err != nil {
+		return nil, err
+	}
+	decl.Parameters = params
+
+	if p.peekTokenIs(token.COLON) {
+		p.nextToken() // consume :
+		p.nextToken() // move to return type
+		returnType, err := p.parseExpression(precedence.LOWEST)
+		if err != nil {
+			return nil, err
+		}
+		decl.ReturnType = returnType
+	}
+
+	if !p.expectPeek(token.SEMICOLON) {
+		return nil, fmt.Errorf("expected ; after native function declaration")
+	}
+
+	return decl, nil
+}
+func (p *Parser) parseFunctionDeclaration() (*ast.FunctionDeclaration, error) {
+	decl := &ast.FunctionDeclaration{Token: p.curToken}
+
+	if !p.expectPeek(token.IDENT) {
+		return nil, fmt.Errorf("expected function name, got %s", p.peekToken.Type)
+	}
+
+	return p.parseFunctionRest(decl)
+}
+
+// parsePlainFunctionDeclaration parses a function defined without a
+// public/stock specifier, such as "entry() <idle> { ... }". The current
+// token is the function name.
+func (p *Parser) parsePlainFunctionDeclaration() (*ast.FunctionDeclaration, error) {
+	decl := &ast.FunctionDeclaration{Token: p.curToken}
+	return p.parseFunctionRest(decl)
+}
+
+// parseFunctionRest parses everything from the function name onwards:
+// parameters, an optional state selector and the body.
+func (p *Parser) parseFunctionRest(decl *ast.FunctionDeclaration) (*ast.FunctionDeclaration, error) {
+	decl.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil, fmt.Errorf("expected ( after function name, got %s", p.peekToken.Type)
+	}
+
+	params, err := p.parseFunctionParameters()
+	if err != nil {
+		return nil, err
+	}
+	decl.Parameters = params
+
+	if p.peekTokenIs(token.LT) {
+		p.nextToken()
+		decl.State, err = p.parseStateSelector()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil, fmt.Errorf("expected { to start function body, got %s", p.peekToken.Type)
+	}
+
+	body, err := p.parseBlockStatement()
+	if err != nil {
+		return nil, err
+	}
+	decl.Body = body
+
+	return decl, nil
+}
+
+// parseStateSelector parses the "<automaton:state1, state2>" suffix of a
+// state function. The current token is the opening '<'.
+func (p *Parser) parseStateSelector() (*ast.StateSelector, error) {
+	sel := &ast.StateSelector{Token: p.curToken}
+
+	if p.peekTokenIs(token.GT) {
+		p.nextToken()
+		return sel, nil
+	}
+
+	if !p.expectPeek(token.IDENT) {
+		return nil, fmt.Errorf("expected state name after <, got %s", p.peekToken.Type)
+	}
+
+	automaton, state := splitStateName(p.curToken.Literal)
+	sel.Automaton = automaton
+	if state != "" {
+		sel.States = append(sel.States, &ast.Identifier{Token: p.curToken, Value: state})
+	}
+
+	for p.peekTokenIs(token.COMMA) {
+		p.nextToken()
+		if !p.expectPeek(token.IDENT) {
+			return nil, fmt.Errorf("expected state name after ',', got %s", p.peekToken.Type)
+		}
+		sel.States = append(sel.States, &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal})
+	}
+
+	if !p.expectPeek(token.GT) {
+		return nil, fmt.Errorf("expected > to close state list, got %s", p.peekToken.Type)
+	}
+
+	return sel, nil
+}
+
+// splitStateName splits "automaton:state" into its two halves. The lexer
+// reads both halves as a single identifier.
+func splitStateName(name string) (string, string) {
+	if i := strings.Index(name, ":"); i >= 0 {
+		return name[:i], name[i+1:]
+	}
+	return "", name
+}
+
+// isFunctionDefinitionAhead reports whether the current identifier starts a
+// function definition without a specifier, i.e. "name(...) {" or
+// "name(...) <states> {".
+func (p *Parser) isFunctionDefinitionAhead() bool {
+	if !p.curTokenIs(token.IDENT) || !p.peekTokenIs(token.LPAREN) {
+		return false
+	}
+
+	depth := 0
+	n := 0
+	for {
+		tok := p.peekTokenAt(n)
+		switch tok.Type {
+		case token.LPAREN:
+			depth++
+		case token.RPAREN:
+			depth--
+		case token.EOF:
+			return false
+		}
+		n++
+		if depth == 0 {
+			break
+		}
+	}
+
+	next := p.peekTokenAt(n)
+	if next.Type == token.LT {
+		for next.Type != token.GT {
+			if next.Type == token.EOF || next.Type == token.SEMICOLON {
+				return false
+			}
+			n++
+			next = p.peekTokenAt(n)
+		}
+		next = p.peekTokenAt(n + 1)
+	}
+
+	return next.Type == token.LBRACE
+}
+func (p *Parser) parseFunctionParameters() ([]*ast.Identifier, error) {
+	identifiers := []*ast.Identifier{}
+
+	if p.peekTokenIs(token.RPAREN) {
+		p.nextToken()
+		return identifiers, nil
+	}
+
+	p.nextToken()
+	ident, err := p.parseParameter()
+	if err != nil {
+		return nil, err
+	}
+	identifiers = append(identifiers, ident)
+
+	for p.peekTokenIs(token.COMMA) {
+		p.nextToken()
+		p.nextToken()
+		ident, err := p.parseParameter()
+		if err != nil {
+			return nil, err
+		}
+		identifiers = append(identifiers, ident)
+	}
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil, fmt.Errorf("expected ')' after function parameters")
+	}
+
+	return identifiers, nil
+}
+
+// parseParameter parses one parameter, keeping its const or reference
+// marker, tag and array dimensions in the name, as in
+// "const string:text[]" or "&Float:x".
+func (p *Parser) parseParameter() (*ast.Identifier, error) {
+	prefix := ""
+	if p.curTokenIs(token.CONST) {
+		prefix = "const "
+		p.nextToken()
+	}
+	if p.curTokenIs(token.AND) {
+		prefix += "&"
+		p.nextToken()
+	}
+	ident := &ast.Identifier{Token: p.curToken, Value: prefix + p.curToken.Literal}
+	if p.peekTokenIs(token.COLON) {
+		p.nextToken()
+		ident.Value += ":" + p.curToken.Literal
+	}
+	for p.peekTokenIs(token.LBRACK) {
+		p.nextToken()
+		// The size is a constant expression, such as MAX_PLAYERS + 1,
+		// kept as written.
+		size := []token.Token{}
+		for depth := 0; !p.peekTokenIs(token.RBRACK) || depth > 0; {
+			switch {
+			case p.peekTokenIs(token.EOF):
+				return nil, fmt.Errorf("expected ] in array parameter %s, got %s", ident.Value, p.peekToken.Type)
+			case p.peekTokenIs(token.LBRACK):
+				depth++
+			case p.peekTokenIs(token.RBRACK):
+				depth--
+			}
+			p.nextToken()
+			size = append(size, p.curToken)
+		}
+		p.nextToken()
+		ident.Value += "[" + tokensText(size) + "]"
+	}
+	return ident, nil
+}
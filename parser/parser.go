@@ -7,19 +7,77 @@ import (
 	"strings"
 
 	"github.com/Tramposo1312/pawn-parser/ast"
-	"github.com/Tramposo1312/pawn-parser/lexer"
 	"github.com/Tramposo1312/pawn-parser/precedence"
 	"github.com/Tramposo1312/pawn-parser/token"
 )
 
+// TokenSource produces the tokens a Parser consumes. A lexer.Lexer gives
+// the unexpanded view of a file, in which #include and #define are kept as
+// directive nodes and every branch of an #if block is preserved; a
+// preprocessor.Preprocessor gives the expanded token stream the compiler
+// would see, with conditions evaluated and only active branches left.
+type TokenSource interface {
+	NextToken() token.Token
+}
+
+// assignmentOperators are '=' and the compound assignments.
+var assignmentOperators = []token.TokenType{
+	token.ASSIGN, token.ADD_ASSIGN, token.SUB_ASSIGN, token.MUL_ASSIGN,
+	token.QUO_ASSIGN, token.REM_ASSIGN, token.AND_ASSIGN, token.OR_ASSIGN,
+	token.XOR_ASSIGN, token.SHL_ASSIGN, token.SHR_ASSIGN,
+}
+
+// Dialect is the flavour of Pawn a Parser accepts.
+type Dialect int
+
+const (
+	// YSI is the open.mp dialect with the constructs YSI's macros add,
+	// such as hooks, timers, foreach loops and inline functions. Its
+	// keywords are only keywords where one of those constructs can start,
+	// so a plain script may still name a variable timer or task.
+	YSI Dialect = iota
+
+	// OpenMP is the language of the community compiler, Pawn 3.10, used
+	// by open.mp, which adds operators such as __emit.
+	OpenMP
+
+	// SAMP is Pawn 3.2 as compiled by SA-MP's pawncc.
+	SAMP
+)
+
+// PawnVersion returns the compiler version the dialect's compiler gives
+// __Pawn: 0x0302 for SA-MP's and 0x030A for the open.mp compiler, which
+// YSI is compiled with too.
+func (d Dialect) PawnVersion() int64 {
+	if d == SAMP {
+		return 0x0302
+	}
+	return 0x030A
+}
+
+// Options configure a Parser.
+type Options struct {
+	// Dialect selects the keywords and grammar extensions accepted. The
+	// zero value is YSI, which accepts everything.
+	Dialect Dialect
+}
+
 type Parser struct {
-	l *lexer.Lexer
+	l TokenSource
 
 	curToken  token.Token
 	peekToken token.Token
 
+	// lookahead holds tokens read past peekToken by peekTokenAt.
+	lookahead []token.Token
+
 	errors []string
 
+	// requireSemicolons is set by "#pragma semicolon 1".
+	requireSemicolons bool
+
+	dialect Dialect
+
 	prefixParseFns map[token.TokenType]prefixParseFn
 	infixParseFns  map[token.TokenType]infixParseFn
 }
@@ -28,10 +86,16 @@ func (p *Parser) TokenPrecedence(tokenType token.TokenType) int {
 	return precedence.GetPrecedence(tokenType)
 }
 
-func New(l *lexer.Lexer) *Parser {
+func New(l TokenSource) *Parser {
+	return NewWithOptions(l, Options{})
+}
+
+// NewWithOptions returns a Parser for the dialect chosen in opts.
+func NewWithOptions(l TokenSource, opts Options) *Parser {
 	p := &Parser{
-		l:      l,
-		errors: []string{},
+		l:       l,
+		errors:  []string{},
+		dialect: opts.Dialect,
 
 		prefixParseFns: make(map[token.TokenType]prefixParseFn),
 		infixParseFns:  make(map[token.TokenType]infixParseFn),
@@ -54,7 +118,12 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerPrefix(token.FUNCTION, p.parseFunctionLiteral)
 	p.registerPrefix(token.NOT, p.parsePrefixExpression)
 	p.registerPrefix(token.MINUS, p.parsePrefixExpression)
+	p.registerPrefix(token.INC, p.parsePrefixExpression)
+	p.registerPrefix(token.DEC, p.parsePrefixExpression)
 	p.registerPrefix(token.LPAREN, p.parseGroupedExpression)
+	if p.dialect == YSI {
+		p.registerPrefix(token.TEXTID, p.parseTextIdentifier)
+	}
 	p.registerPrefix(token.INT, p.parseLiteral)
 	p.registerPrefix(token.FLOAT, p.parseLiteral)
 	p.registerPrefix(token.STRING, p.parseLiteral)
@@ -73,13 +142,45 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerInfix(token.LT, p.parseInfixExpression)
 	p.registerInfix(token.GT, p.parseInfixExpression)
 	p.registerInfix(token.LPAREN, p.parseCallExpression)
+	p.registerInfix(token.LBRACK, p.parseIndexExpression)
+	p.registerInfix(token.INC, p.parsePostfixExpression)
+	p.registerInfix(token.DEC, p.parsePostfixExpression)
+	for _, op := range assignmentOperators {
+		p.registerInfix(op, p.parseAssignExpression)
+	}
 
 	return p
 }
 
 func (p *Parser) nextToken() {
 	p.curToken = p.peekToken
-	p.peekToken = p.l.NextToken()
+	if len(p.lookahead) > 0 {
+		p.peekToken = p.lookahead[0]
+		p.lookahead = p.lookahead[1:]
+		return
+	}
+	p.peekToken = p.readToken()
+}
+
+// readToken pulls the next token from the lexer, skipping comments.
+func (p *Parser) readToken() token.Token {
+	tok := p.l.NextToken()
+	for tok.Type == token.COMMENT {
+		tok = p.l.NextToken()
+	}
+	return tok
+}
+
+// peekTokenAt returns the token n positions after peekToken without
+// consuming anything; peekTokenAt(0) is peekToken itself.
+func (p *Parser) peekTokenAt(n int) token.Token {
+	if n == 0 {
+		return p.peekToken
+	}
+	for len(p.lookahead) < n {
+		p.lookahead = append(p.lookahead, p.readToken())
+	}
+	return p.lookahead[n-1]
 }
 
 func (p *Parser) curTokenIs(t token.TokenType) bool {
@@ -99,6 +200,19 @@ func (p *Parser) expectPeek(t token.TokenType) bool {
 	return false
 }
 
+// endStatement consumes the ';' that terminates a simple statement. The
+// semicolon is optional unless "#pragma semicolon 1" is in effect.
+func (p *Parser) endStatement() error {
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+		return nil
+	}
+	if p.requireSemicolons {
+		return fmt.Errorf("expected ';' after statement on line %d, got %s", p.curToken.Line, p.peekToken.Type)
+	}
+	return nil
+}
+
 func (p *Parser) Errors() []string {
 	return p.errors
 }
@@ -125,7 +239,7 @@ func (p *Parser) ParseProgram() (*ast.Program, error) {
 	for !p.curTokenIs(token.EOF) {
 		stmt, err := p.parseStatement()
 		if err != nil {
-			errors = append(errors, err.Error())
+			errors = append(errors, p.describeError(err))
 		} else {
 			program.Statements = append(program.Statements, stmt)
 		}
@@ -139,6 +253,15 @@ func (p *Parser) ParseProgram() (*ast.Program, error) {
 	return program, nil
 }
 
+// describeError adds the macro expansions the current token came from to
+// err, so that a mistake in a #define is traced back to it.
+func (p *Parser) describeError(err error) string {
+	if p.curToken.Origin == nil {
+		return err.Error()
+	}
+	return err.Error() + "\n\t" + strings.ReplaceAll(p.curToken.Origin.Describe(), "\n", "\n\t")
+}
+
 func (p *Parser) peekPrecedence() int {
 	return precedence.GetPrecedence(p.peekToken.Type)
 }
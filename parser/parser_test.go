@@ -1,10 +1,13 @@
 package parser
 
 import (
+	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/Tramposo1312/pawn-parser/ast"
 	"github.com/Tramposo1312/pawn-parser/lexer"
+	"github.com/Tramposo1312/pawn-parser/token"
 )
 
 func TestLetStatements(t *testing.T) {
@@ -431,33 +434,284 @@ func TestIncludeDirective(t *testing.T) {
 	}
 }
 
-func TestDefineDirective(t *testing.T) {
-	input := `#define MAX_PLAYERS 50`
+func TestIncludeForms(t *testing.T) {
+	tests := []struct {
+		input    string
+		path     string
+		system   bool
+		optional bool
+		str      string
+	}{
+		{"#include <a_samp>", "a_samp", true, false, "#include <a_samp>"},
+		{"#include <YSI_Coding\\y_hooks>", "YSI_Coding\\y_hooks", true, false, "#include <YSI_Coding\\y_hooks>"},
+		{"#include \"../include/utils.inc\"", "../include/utils.inc", false, false, "#include \"../include/utils.inc\""},
+		{"#tryinclude <streamer>", "streamer", true, true, "#tryinclude <streamer>"},
+		{"#include sscanf2", "sscanf2", true, false, "#include <sscanf2>"},
+	}
 
-	l := lexer.New(input)
-	p := New(l)
-	program, err := p.ParseProgram()
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input + "\nnew x = 1;"))
+		program, err := p.ParseProgram()
+		if err != nil {
+			t.Fatalf("ParseProgram(%q) failed: %s", tt.input, err)
+		}
+		if len(program.Statements) != 2 {
+			t.Fatalf("%q: expected 2 statements. got=%d", tt.input, len(program.Statements))
+		}
+
+		stmt, ok := program.Statements[0].(*ast.IncludeDirective)
+		if !ok {
+			t.Fatalf("program.Statements[0] is not ast.IncludeDirective. got=%T",
+				program.Statements[0])
+		}
+		if stmt.Path != tt.path || stmt.System != tt.system || stmt.Optional != tt.optional {
+			t.Errorf("%q: got Path=%q System=%t Optional=%t", tt.input, stmt.Path, stmt.System, stmt.Optional)
+		}
+		if stmt.String() != tt.str {
+			t.Errorf("stmt.String() wrong. expected=%q, got=%q", tt.str, stmt.String())
+		}
+	}
+}
+
+func TestConditionalDirective(t *testing.T) {
+	input := `
+#if defined FOO && MAX_PLAYERS > 100
+new a = 1;
+#ifndef BAR
+new b = 2;
+#endif
+#elseif MAX_PLAYERS == 50
+#else
+new c = 3;
+#endif
+new d = 4;
+`
+
+	program, err := New(lexer.New(input)).ParseProgram()
 	if err != nil {
 		t.Fatalf("ParseProgram() failed: %s", err)
 	}
+	if len(program.Statements) != 2 {
+		t.Fatalf("program.Statements does not contain 2 statements. got=%d",
+			len(program.Statements))
+	}
+
+	cond, ok := program.Statements[0].(*ast.ConditionalDirective)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.ConditionalDirective. got=%T",
+			program.Statements[0])
+	}
 
+	tests := []struct {
+		directive  string
+		condition  string
+		statements int
+	}{
+		{"#if", "defined FOO && MAX_PLAYERS > 100", 2},
+		{"#elseif", "MAX_PLAYERS == 50", 0},
+		{"#else", "", 1},
+	}
+	if len(cond.Branches) != len(tests) {
+		t.Fatalf("expected %d branches. got=%d", len(tests), len(cond.Branches))
+	}
+	for i, tt := range tests {
+		branch := cond.Branches[i]
+		if branch.Token.Literal != tt.directive || branch.Condition != tt.condition || len(branch.Body) != tt.statements {
+			t.Errorf("branch %d wrong. got %s %q with %d statements",
+				i, branch.Token.Literal, branch.Condition, len(branch.Body))
+		}
+	}
+
+	nested, ok := cond.Branches[0].Body[1].(*ast.ConditionalDirective)
+	if !ok || nested.Branches[0].Condition != "BAR" {
+		t.Errorf("nested #ifndef not parsed. got=%s", cond.Branches[0].Body[1].String())
+	}
+	if cond.End.Literal != "#endif" {
+		t.Errorf("cond.End wrong. got=%q", cond.End.Literal)
+	}
+}
+
+func TestMultiLineConditionalDirective(t *testing.T) {
+	input := "#if defined FOO && \\\n\tdefined BAR\nnew a = 1;\n#endif\n"
+
+	program, err := New(lexer.New(input)).ParseProgram()
+	if err != nil {
+		t.Fatalf("ParseProgram() failed: %s", err)
+	}
 	if len(program.Statements) != 1 {
 		t.Fatalf("program.Statements does not contain 1 statement. got=%d",
 			len(program.Statements))
 	}
 
-	stmt, ok := program.Statements[0].(*ast.DefineDirective)
+	cond, ok := program.Statements[0].(*ast.ConditionalDirective)
 	if !ok {
-		t.Fatalf("program.Statements[0] is not ast.DefineDirective. got=%T",
+		t.Fatalf("program.Statements[0] is not ast.ConditionalDirective. got=%T",
 			program.Statements[0])
 	}
+	branch := cond.Branches[0]
+	if branch.Condition != "defined FOO && defined BAR" || len(branch.Body) != 1 {
+		t.Errorf("branch wrong. got %q with %d statements", branch.Condition, len(branch.Body))
+	}
+}
 
-	if stmt.Name != "MAX_PLAYERS" {
-		t.Errorf("stmt.Name not %s. got=%s", "MAX_PLAYERS", stmt.Name)
+func TestIncludeGuard(t *testing.T) {
+	input := `
+#if defined _samp_included
+	#endinput
+#endif
+#define _samp_included
+`
+
+	program, err := New(lexer.New(input)).ParseProgram()
+	if err != nil {
+		t.Fatalf("ParseProgram() failed: %s", err)
+	}
+	if len(program.Statements) != 2 {
+		t.Fatalf("program.Statements does not contain 2 statements. got=%d",
+			len(program.Statements))
 	}
 
-	if !testIntegerLiteral(t, stmt.Value, 50) {
-		return
+	cond, ok := program.Statements[0].(*ast.ConditionalDirective)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.ConditionalDirective. got=%T",
+			program.Statements[0])
+	}
+	if _, ok := cond.Branches[0].Body[0].(*ast.EndInputDirective); !ok {
+		t.Errorf("branch body is not ast.EndInputDirective. got=%T", cond.Branches[0].Body[0])
+	}
+}
+
+func TestConditionalDirectiveErrors(t *testing.T) {
+	tests := []string{
+		"#if 1\nnew a = 1;",
+		"#if\n#endif",
+		"#else\n",
+		"#endif\n",
+		"#if 1\n#else\n#elseif 2\n#endif",
+		"#if 1\n#else 2\n#endif",
+	}
+
+	for _, input := range tests {
+		if _, err := New(lexer.New(input)).ParseProgram(); err == nil {
+			t.Errorf("expected an error for %q", input)
+		}
+	}
+}
+
+func TestOtherDirectives(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"#undef MAX_PLAYERS", "#undef MAX_PLAYERS"},
+		{"#error This include needs open.mp", "#error This include needs open.mp"},
+		{"#warning Don't use this", "#warning Don't use this"},
+		{"#assert MAX_PLAYERS <= 1000", "#assert MAX_PLAYERS <= 1000"},
+		{"#line 10 * 10", "#line 100"},
+		{"#file \"generated.pwn\"", "#file \"generated.pwn\""},
+		{"#section data", "#section data"},
+		{"#section", "#section"},
+	}
+
+	for _, tt := range tests {
+		program, err := New(lexer.New(tt.input + "\nnew x = 1;")).ParseProgram()
+		if err != nil {
+			t.Fatalf("ParseProgram(%q) failed: %s", tt.input, err)
+		}
+		if len(program.Statements) != 2 {
+			t.Fatalf("%q: expected 2 statements. got=%d", tt.input, len(program.Statements))
+		}
+		if got := program.Statements[0].String(); got != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, got)
+		}
+	}
+}
+
+func TestDefineDirective(t *testing.T) {
+	tests := []struct {
+		input        string
+		name         string
+		pattern      string
+		substitution string
+		value        int64
+		constant     bool
+	}{
+		{"#define MAX_PLAYERS 50", "MAX_PLAYERS", "MAX_PLAYERS", "50", 50, true},
+		{"#define COLOR_RED 0xFF0000FF", "COLOR_RED", "COLOR_RED", "0xFF0000FF", -16776961, true},
+		{"#define MAX_VEHICLES (MAX_PLAYERS * 2)", "MAX_VEHICLES", "MAX_VEHICLES", "(MAX_PLAYERS * 2)", 0, false},
+		{"#define FOREVER for(;;)", "FOREVER", "FOREVER", "for(;;)", 0, false},
+		{"#define DIALOG:%0 (%0)", "DIALOG", "DIALOG:%0", "(%0)", 0, false},
+		{"#define CMD:%0(%1) forward cmd_%0(%1); public cmd_%0(%1)", "CMD", "CMD:%0(%1)", "forward cmd_%0(%1); public cmd_%0(%1)", 0, false},
+		{"#define _samp_included", "_samp_included", "_samp_included", "", 0, false},
+		{"#define X(%0) 5", "X", "X(%0)", "5", 0, false},
+		{"#define KEY:%0 1", "KEY", "KEY:%0", "1", 0, false},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input + "\nnew x = 1;")
+		p := New(l)
+		program, err := p.ParseProgram()
+		if err != nil {
+			t.Fatalf("ParseProgram(%q) failed: %s", tt.input, err)
+		}
+
+		if len(program.Statements) != 2 {
+			t.Fatalf("program.Statements does not contain 2 statements. got=%d",
+				len(program.Statements))
+		}
+
+		stmt, ok := program.Statements[0].(*ast.DefineDirective)
+		if !ok {
+			t.Fatalf("program.Statements[0] is not ast.DefineDirective. got=%T",
+				program.Statements[0])
+		}
+
+		if stmt.Name != tt.name || stmt.Pattern != tt.pattern || stmt.Substitution != tt.substitution {
+			t.Errorf("%q: got Name=%q Pattern=%q Substitution=%q", tt.input,
+				stmt.Name, stmt.Pattern, stmt.Substitution)
+		}
+		if stmt.String() != tt.input {
+			t.Errorf("stmt.String() not %q. got=%q", tt.input, stmt.String())
+		}
+
+		value, constant := stmt.ConstantValue()
+		if constant != tt.constant || value != tt.value {
+			t.Errorf("%q: ConstantValue() = %d, %t. expected %d, %t", tt.input,
+				value, constant, tt.value, tt.constant)
+		}
+	}
+}
+
+func TestMultiLineDefineDirective(t *testing.T) {
+	tests := []struct {
+		input        string
+		pattern      string
+		substitution string
+	}{
+		{"#define MAX 10 + \\\n5", "MAX", "10 + 5"},
+		{"#define CMD:%0(%1) \\\n\tforward cmd_%0(%1); \\\n\tpublic cmd_%0(%1)",
+			"CMD:%0(%1)", "forward cmd_%0(%1); public cmd_%0(%1)"},
+		{"#define EMPTY \\\n", "EMPTY", ""},
+	}
+
+	for _, tt := range tests {
+		program, err := New(lexer.New(tt.input + "\nnew x = 1;")).ParseProgram()
+		if err != nil {
+			t.Fatalf("ParseProgram(%q) failed: %s", tt.input, err)
+		}
+		if len(program.Statements) != 2 {
+			t.Fatalf("%q: program.Statements does not contain 2 statements. got=%d",
+				tt.input, len(program.Statements))
+		}
+
+		stmt, ok := program.Statements[0].(*ast.DefineDirective)
+		if !ok {
+			t.Fatalf("program.Statements[0] is not ast.DefineDirective. got=%T",
+				program.Statements[0])
+		}
+		if stmt.Pattern != tt.pattern || stmt.Substitution != tt.substitution {
+			t.Errorf("%q: got Pattern=%q Substitution=%q", tt.input, stmt.Pattern, stmt.Substitution)
+		}
 	}
 }
 
@@ -507,3 +761,710 @@ func TestNativeFunctionDeclaration(t *testing.T) {
 		}
 	}
 }
+
+func TestFunctionParameters(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"f(playerid, string:text[]) { }", []string{"playerid", "string:text[]"}},
+		{"f(a[MAX_PLAYERS]) { }", []string{"a[MAX_PLAYERS]"}},
+		{"f(a[MAX + 1], b[2][MAX * (2 + 1)]) { }", []string{"a[MAX + 1]", "b[2][MAX * (2 + 1)]"}},
+		{"f(const string:text[], &Float:x) { }", []string{"const string:text[]", "&Float:x"}},
+	}
+
+	for _, tt := range tests {
+		program, err := parseProgram(tt.input)
+		if err != nil {
+			t.Fatalf("parse error for %q: %v", tt.input, err)
+		}
+		decl := program.Statements[0].(*ast.FunctionDeclaration)
+		params := []string{}
+		for _, param := range decl.Parameters {
+			params = append(params, param.Value)
+		}
+		if fmt.Sprint(params) != fmt.Sprint(tt.expected) {
+			t.Errorf("%q: expected=%v, got=%v", tt.input, tt.expected, params)
+		}
+	}
+
+	if _, err := parseProgram("f(a[MAX"); err == nil {
+		t.Errorf("expected an error for an unclosed array size")
+	}
+}
+
+func TestStateFunctions(t *testing.T) {
+	input := `
+public OnTick() <idle> { return 0; }
+public OnTick() <auto:running, paused> { return 1; }
+public OnTick() <> { return 2; }
+entry() <auto:running> { return 3; }
+`
+
+	program, err := parseProgram(input)
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+
+	tests := []struct {
+		expectedName      string
+		expectedAutomaton string
+		expectedStates    []string
+		expectedFallback  bool
+		expectedEntry     bool
+	}{
+		{"OnTick", "", []string{"idle"}, false, false},
+		{"OnTick", "auto", []string{"running", "paused"}, false, false},
+		{"OnTick", "", []string{}, true, false},
+		{"entry", "auto", []string{"running"}, false, true},
+	}
+
+	if len(program.Statements) != len(tests) {
+		t.Fatalf("program.Statements does not contain %d statements. got=%d",
+			len(tests), len(program.Statements))
+	}
+
+	for i, tt := range tests {
+		decl, ok := program.Statements[i].(*ast.FunctionDeclaration)
+		if !ok {
+			t.Fatalf("program.Statements[%d] is not ast.FunctionDeclaration. got=%T",
+				i, program.Statements[i])
+		}
+		if decl.Name.Value != tt.expectedName {
+			t.Errorf("decl.Name not %s. got=%s", tt.expectedName, decl.Name.Value)
+		}
+		if decl.IsEntry() != tt.expectedEntry {
+			t.Errorf("decl.IsEntry() not %t", tt.expectedEntry)
+		}
+		if decl.State == nil {
+			t.Fatalf("decl.State is nil")
+		}
+		if decl.State.Automaton != tt.expectedAutomaton {
+			t.Errorf("decl.State.Automaton not %q. got=%q", tt.expectedAutomaton, decl.State.Automaton)
+		}
+		if decl.State.IsFallback() != tt.expectedFallback {
+			t.Errorf("decl.State.IsFallback() not %t", tt.expectedFallback)
+		}
+		if len(decl.State.States) != len(tt.expectedStates) {
+			t.Fatalf("wrong number of states. want %d, got=%d",
+				len(tt.expectedStates), len(decl.State.States))
+		}
+		for j, name := range tt.expectedStates {
+			if decl.State.States[j].Value != name {
+				t.Errorf("state %d not %s. got=%s", j, name, decl.State.States[j].Value)
+			}
+		}
+	}
+}
+
+func TestStateStatements(t *testing.T) {
+	tests := []struct {
+		input             string
+		expectedAutomaton string
+		expectedState     string
+		expectedCondition bool
+		expectedString    string
+	}{
+		{"state idle;", "", "idle", false, "state idle;"},
+		{"state auto:running;", "auto", "running", false, "state auto:running;"},
+		{"state (x > 5) auto:paused;", "auto", "paused", true, "state (x > 5) auto:paused;"},
+		{"state (ready) auto:running;", "auto", "running", true, "state (ready) auto:running;"},
+		{"state (!ready) auto:running;", "auto", "running", true, "state (!ready) auto:running;"},
+		{"state (a == b) auto:running;", "auto", "running", true, "state (a == b) auto:running;"},
+		{"state (f(a) + g(b)) auto:running;", "auto", "running", true, "state (f(a) + g(b)) auto:running;"},
+	}
+
+	for _, tt := range tests {
+		program, err := parseProgram(tt.input)
+		if err != nil {
+			t.Fatalf("parse error: %v", err)
+		}
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain 1 statement. got=%d",
+				len(program.Statements))
+		}
+
+		stmt, ok := program.Statements[0].(*ast.StateStatement)
+		if !ok {
+			t.Fatalf("program.Statements[0] is not ast.StateStatement. got=%T",
+				program.Statements[0])
+		}
+
+		if stmt.Automaton != tt.expectedAutomaton {
+			t.Errorf("stmt.Automaton not %q. got=%q", tt.expectedAutomaton, stmt.Automaton)
+		}
+		if stmt.State.Value != tt.expectedState {
+			t.Errorf("stmt.State not %s. got=%s", tt.expectedState, stmt.State.Value)
+		}
+		if (stmt.Condition != nil) != tt.expectedCondition {
+			t.Errorf("stmt.Condition presence not %t", tt.expectedCondition)
+		}
+		if stmt.String() != tt.expectedString {
+			t.Errorf("stmt.String() not %q. got=%q", tt.expectedString, stmt.String())
+		}
+	}
+}
+
+func TestSleepExitAssertStatements(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedString string
+	}{
+		{"sleep;", "sleep;"},
+		{"sleep 100;", "sleep 100;"},
+		{"exit;", "exit;"},
+		{"exit 1;", "exit 1;"},
+		{"assert x > 0;", "assert (x > 0);"},
+		{"assert IsValid(playerid);", "assert IsValid(playerid);"},
+	}
+
+	for _, tt := range tests {
+		program, err := parseProgram(tt.input)
+		if err != nil {
+			t.Fatalf("parse error: %v", err)
+		}
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain 1 statement. got=%d",
+				len(program.Statements))
+		}
+
+		stmt := program.Statements[0]
+		switch s := stmt.(type) {
+		case *ast.SleepStatement, *ast.ExitStatement:
+		case *ast.AssertStatement:
+			if s.Condition == nil {
+				t.Fatalf("assert condition is nil")
+			}
+		default:
+			t.Fatalf("unexpected statement type %T for %q", stmt, tt.input)
+		}
+
+		if stmt.String() != tt.expectedString {
+			t.Errorf("stmt.String() not %q. got=%q", tt.expectedString, stmt.String())
+		}
+	}
+}
+
+func TestAssertInsideFunction(t *testing.T) {
+	input := `
+stock RunTests()
+{
+	assert Add(1, 2) == 3;
+	sleep 10;
+	state done;
+	exit;
+}
+`
+
+	program, err := parseProgram(input)
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+
+	decl, ok := program.Statements[0].(*ast.FunctionDeclaration)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.FunctionDeclaration. got=%T",
+			program.Statements[0])
+	}
+
+	if len(decl.Body.Statements) != 4 {
+		t.Fatalf("function body does not contain 4 statements. got=%d",
+			len(decl.Body.Statements))
+	}
+
+	if _, ok := decl.Body.Statements[0].(*ast.AssertStatement); !ok {
+		t.Errorf("Statements[0] is not ast.AssertStatement. got=%T", decl.Body.Statements[0])
+	}
+	if _, ok := decl.Body.Statements[1].(*ast.SleepStatement); !ok {
+		t.Errorf("Statements[1] is not ast.SleepStatement. got=%T", decl.Body.Statements[1])
+	}
+	if _, ok := decl.Body.Statements[2].(*ast.StateStatement); !ok {
+		t.Errorf("Statements[2] is not ast.StateStatement. got=%T", decl.Body.Statements[2])
+	}
+	if _, ok := decl.Body.Statements[3].(*ast.ExitStatement); !ok {
+		t.Errorf("Statements[3] is not ast.ExitStatement. got=%T", decl.Body.Statements[3])
+	}
+}
+
+func TestForStatementClauses(t *testing.T) {
+	tests := []struct {
+		input             string
+		expectedInit      string
+		expectedCondition string
+		expectedUpdate    string
+	}{
+		{"for (i = 0, j = 10; i < j; i++, j--) { }", "((i = 0), (j = 10))", "(i < j)", "((i++), (j--))"},
+		{"for (new i = 0; i < 5; i++) { }", "new i = 0;", "(i < 5)", "(i++)"},
+		{"for (;;) { }", "", "", ""},
+		{"for (; i < 5;) { }", "", "(i < 5)", ""},
+	}
+
+	for _, tt := range tests {
+		program, err := parseProgram(tt.input)
+		if err != nil {
+			t.Fatalf("parse error for %q: %v", tt.input, err)
+		}
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain 1 statement. got=%d",
+				len(program.Statements))
+		}
+
+		stmt, ok := program.Statements[0].(*ast.ForStatement)
+		if !ok {
+			t.Fatalf("program.Statements[0] is not ast.ForStatement. got=%T",
+				program.Statements[0])
+		}
+
+		if got := nodeString(stmt.Init); got != tt.expectedInit {
+			t.Errorf("init wrong for %q. expected=%q, got=%q", tt.input, tt.expectedInit, got)
+		}
+		if got := nodeString(stmt.Condition); got != tt.expectedCondition {
+			t.Errorf("condition wrong for %q. expected=%q, got=%q", tt.input, tt.expectedCondition, got)
+		}
+		if got := nodeString(stmt.Update); got != tt.expectedUpdate {
+			t.Errorf("update wrong for %q. expected=%q, got=%q", tt.input, tt.expectedUpdate, got)
+		}
+	}
+}
+
+func nodeString(n ast.Node) string {
+	if n == nil {
+		return ""
+	}
+	return n.String()
+}
+
+func TestEmptyStatements(t *testing.T) {
+	input := `
+;
+stock Foo()
+{
+	;;
+	x = 1;;
+}
+`
+
+	program, err := parseProgram(input)
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+
+	if len(program.Statements) != 2 {
+		t.Fatalf("program.Statements does not contain 2 statements. got=%d",
+			len(program.Statements))
+	}
+
+	if _, ok := program.Statements[0].(*ast.EmptyStatement); !ok {
+		t.Fatalf("program.Statements[0] is not ast.EmptyStatement. got=%T",
+			program.Statements[0])
+	}
+
+	decl := program.Statements[1].(*ast.FunctionDeclaration)
+	expected := []string{"*ast.EmptyStatement", "*ast.EmptyStatement", "*ast.ExpressionStatement", "*ast.EmptyStatement"}
+	if len(decl.Body.Statements) != len(expected) {
+		t.Fatalf("function body does not contain %d statements. got=%d",
+			len(expected), len(decl.Body.Statements))
+	}
+	for i, want := range expected {
+		if got := fmt.Sprintf("%T", decl.Body.Statements[i]); got != want {
+			t.Errorf("Statements[%d] not %s. got=%s", i, want, got)
+		}
+	}
+}
+
+func TestCommaAndAssignExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"a = b = c;", "(a = (b = c))"},
+		{"a += 1, b -= 2;", "((a += 1), (b -= 2))"},
+		{"Foo(a, b);", "Foo(a, b)"},
+		{"++i;", "(++i)"},
+	}
+
+	for _, tt := range tests {
+		program, err := parseProgram(tt.input)
+		if err != nil {
+			t.Fatalf("parse error for %q: %v", tt.input, err)
+		}
+
+		if actual := program.String(); actual != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, actual)
+		}
+	}
+}
+
+func TestEmitDirective(t *testing.T) {
+	input := `
+stock GetFrame()
+{
+	new frame = 0;
+	#emit LOAD.S.pri 12
+	#emit STOR.S.pri frame
+	#emit CONST.alt -4
+	#emit JZER skip
+	#emit ZERO.pri
+	return frame;
+}
+`
+
+	program, err := parseProgram(input)
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+
+	decl := program.Statements[0].(*ast.FunctionDeclaration)
+
+	tests := []struct {
+		expectedOpcode string
+		expectedKinds  []ast.EmitOperandKind
+		expectedValues []string
+	}{
+		{"load.s.pri", []ast.EmitOperandKind{ast.EmitNumber}, []string{"12"}},
+		{"stor.s.pri", []ast.EmitOperandKind{ast.EmitSymbol}, []string{"frame"}},
+		{"const.alt", []ast.EmitOperandKind{ast.EmitNumber}, []string{"-4"}},
+		{"jzer", []ast.EmitOperandKind{ast.EmitLabel}, []string{"skip"}},
+		{"zero.pri", nil, nil},
+	}
+
+	if len(decl.Body.Statements) != len(tests)+2 {
+		t.Fatalf("function body does not contain %d statements. got=%d",
+			len(tests)+2, len(decl.Body.Statements))
+	}
+
+	for i, tt := range tests {
+		emit, ok := decl.Body.Statements[i+1].(*ast.EmitDirective)
+		if !ok {
+			t.Fatalf("Statements[%d] is not ast.EmitDirective. got=%T",
+				i+1, decl.Body.Statements[i+1])
+		}
+		if emit.Instruction.Opcode != tt.expectedOpcode {
+			t.Errorf("opcode not %s. got=%s", tt.expectedOpcode, emit.Instruction.Opcode)
+		}
+		if len(emit.Instruction.Operands) != len(tt.expectedKinds) {
+			t.Fatalf("wrong number of operands for %s. got=%d",
+				tt.expectedOpcode, len(emit.Instruction.Operands))
+		}
+		for j, op := range emit.Instruction.Operands {
+			if op.Kind != tt.expectedKinds[j] || op.Value != tt.expectedValues[j] {
+				t.Errorf("operand %d of %s wrong. got=%v %q", j, tt.expectedOpcode, op.Kind, op.Value)
+			}
+		}
+	}
+}
+
+func TestEmitExpression(t *testing.T) {
+	input := `new addr = __emit(load.s.pri 12, add.c 4, load.i);`
+
+	program, err := parseProgram(input)
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+
+	stmt := program.Statements[0].(*ast.LetStatement)
+	emit, ok := stmt.Value.(*ast.EmitExpression)
+	if !ok {
+		t.Fatalf("stmt.Value is not ast.EmitExpression. got=%T", stmt.Value)
+	}
+
+	expected := "__emit(load.s.pri 12, add.c 4, load.i)"
+	if emit.String() != expected {
+		t.Errorf("emit.String() not %q. got=%q", expected, emit.String())
+	}
+}
+
+func TestOpenMPOperators(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`new name = __nameof(OnPlayerConnect);`, `new name = __nameof(OnPlayerConnect);`},
+		{`new addr = __addressof(Callback);`, `new addr = __addressof(Callback);`},
+		{`__static_assert(MAX_PLAYERS > 0, "no players");`, `__static_assert((MAX_PLAYERS > 0), "no players")`},
+		{`new ok = __static_check(MAX_PLAYERS > 0);`, `new ok = __static_check((MAX_PLAYERS > 0));`},
+		{`__pragma("naked");`, `__pragma("naked")`},
+		{`__pragma("unused a, b", "warning disable 213");`, `__pragma("unused a, b", "warning disable 213")`},
+	}
+
+	for _, tt := range tests {
+		program, err := parseProgram(tt.input)
+		if err != nil {
+			t.Fatalf("parse error for %q: %v", tt.input, err)
+		}
+		if got := program.String(); got != tt.expected {
+			t.Errorf("%q: expected=%q, got=%q", tt.input, tt.expected, got)
+		}
+	}
+
+	program, err := parseProgram(`__pragma("unused a, b", "warning disable 213");`)
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	pragma, ok := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.PragmaExpression)
+	if !ok {
+		t.Fatalf("not ast.PragmaExpression. got=%T", program.Statements[0].(*ast.ExpressionStatement).Expression)
+	}
+	if len(pragma.Pragmas) != 2 {
+		t.Fatalf("expected 2 pragmas. got=%d", len(pragma.Pragmas))
+	}
+	if fmt.Sprint(pragma.Pragmas[0].Symbols) != "[a b]" {
+		t.Errorf("unused symbols wrong. got=%v", pragma.Pragmas[0].Symbols)
+	}
+	if pragma.Pragmas[1].Action != "disable" || fmt.Sprint(pragma.Pragmas[1].Warnings) != "[213]" {
+		t.Errorf("warning pragma wrong. got=%s %v", pragma.Pragmas[1].Action, pragma.Pragmas[1].Warnings)
+	}
+
+	// Errors point at the pragma in the source, not into the string.
+	_, err = parseProgram("f()\n{\n\t__pragma(\"semicolon\");\n}")
+	if err == nil || !strings.Contains(err.Error(), "line 3") {
+		t.Errorf("expected an error on line 3. got=%v", err)
+	}
+	str := token.Token{Type: token.STRING, Literal: "warning disable 213", File: "a.pwn", Line: 3, Column: 11}
+	tokens := pragmaTokens(str)
+	if last := tokens[len(tokens)-1]; last.Literal != "213" || last.Line != 3 || last.Column != 28 {
+		t.Errorf("pragma token position wrong. got=%+v", last)
+	}
+
+	// SA-MP's compiler has none of these; they are ordinary calls.
+	p := NewWithOptions(lexer.New(`__nameof(OnPlayerConnect);`), Options{Dialect: SAMP})
+	program, err = p.ParseProgram()
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	if _, ok := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.CallExpression); !ok {
+		t.Errorf("SAMP: __nameof is not a call. got=%s", program.String())
+	}
+}
+
+func TestPragmaExpressionIsLocal(t *testing.T) {
+	input := "f() { __pragma(\"semicolon 1\"); }\ng() { return 1 }\n"
+
+	program, err := parseProgram(input)
+	if err != nil {
+		t.Fatalf("__pragma changed parsing after its function: %v", err)
+	}
+	if len(program.Statements) != 2 {
+		t.Fatalf("program.Statements does not contain 2 statements. got=%d", len(program.Statements))
+	}
+}
+
+func TestOpenMPOperatorErrors(t *testing.T) {
+	tests := []string{
+		"new x = __nameof(1);",
+		"new x = __addressof(a, b);",
+		"__static_assert();",
+		"__static_assert(1, \"a\", 2);",
+		"__pragma(naked);",
+		"__pragma(\"\");",
+		"__pragma(\"semicolon x\");",
+	}
+
+	for _, input := range tests {
+		if _, err := parseProgram(input); err == nil {
+			t.Errorf("expected parse error for %q", input)
+		}
+	}
+}
+
+func TestEmitValidation(t *testing.T) {
+	tests := []string{
+		"#emit LOAD.S.bogus 12",
+		"#emit PUSH.pri 5",
+		"#emit LOAD.pri",
+		"new x = __emit(frobnicate);",
+	}
+
+	for _, input := range tests {
+		if _, err := parseProgram(input); err == nil {
+			t.Errorf("expected parse error for %q", input)
+		}
+	}
+}
+
+func TestPragmaDirectives(t *testing.T) {
+	input := `
+#pragma unused playerid, reason
+#pragma deprecated Use SendClientMessageEx instead
+#pragma dynamic 65536
+#pragma tabsize 4
+#pragma ctrlchar '$'
+#pragma warning push
+#pragma warning disable 213, 234
+#pragma option -d3
+#pragma naked
+#pragma rational Float(3)
+#pragma library sampgdk
+#pragma dynamic 4 * 1024
+#pragma dynamic MAX_SIZE * 4
+`
+
+	program, err := parseProgram(input)
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+
+	if len(program.Statements) != 13 {
+		t.Fatalf("program.Statements does not contain 13 statements. got=%d",
+			len(program.Statements))
+	}
+
+	pragmas := []*ast.PragmaDirective{}
+	for i, stmt := range program.Statements {
+		pragma, ok := stmt.(*ast.PragmaDirective)
+		if !ok {
+			t.Fatalf("program.Statements[%d] is not ast.PragmaDirective. got=%T", i, stmt)
+		}
+		pragmas = append(pragmas, pragma)
+	}
+
+	if got := fmt.Sprint(pragmas[0].Symbols); got != "[playerid reason]" {
+		t.Errorf("unused symbols wrong. got=%s", got)
+	}
+	if pragmas[1].Message != "Use SendClientMessageEx instead" {
+		t.Errorf("deprecated message wrong. got=%q", pragmas[1].Message)
+	}
+	if pragmas[2].Value != 65536 || pragmas[3].Value != 4 {
+		t.Errorf("dynamic/tabsize values wrong. got=%d, %d", pragmas[2].Value, pragmas[3].Value)
+	}
+	if pragmas[4].Value != '$' {
+		t.Errorf("ctrlchar value wrong. got=%d", pragmas[4].Value)
+	}
+	if pragmas[5].Action != "push" {
+		t.Errorf("warning action wrong. got=%q", pragmas[5].Action)
+	}
+	if pragmas[6].Action != "disable" || fmt.Sprint(pragmas[6].Warnings) != "[213 234]" {
+		t.Errorf("warning disable wrong. got=%q %v", pragmas[6].Action, pragmas[6].Warnings)
+	}
+	if pragmas[7].Arguments != "-d3" || fmt.Sprint(pragmas[7].Options) != "[-d3]" {
+		t.Errorf("option wrong. got=%q %v", pragmas[7].Arguments, pragmas[7].Options)
+	}
+	if pragmas[8].Name != "naked" {
+		t.Errorf("naked name wrong. got=%q", pragmas[8].Name)
+	}
+	if fmt.Sprint(pragmas[9].Symbols) != "[Float]" || pragmas[9].Value != 3 {
+		t.Errorf("rational wrong. got=%v %d", pragmas[9].Symbols, pragmas[9].Value)
+	}
+	if pragmas[10].Name != "library" || pragmas[10].Arguments != "sampgdk" {
+		t.Errorf("unknown pragma wrong. got=%s %q", pragmas[10].Name, pragmas[10].Arguments)
+	}
+	if pragmas[11].Value != 4096 {
+		t.Errorf("dynamic expression wrong. got=%d", pragmas[11].Value)
+	}
+	if pragmas[12].Value != 0 || pragmas[12].Arguments != "MAX_SIZE * 4" {
+		t.Errorf("dynamic with a constant wrong. got=%d %q", pragmas[12].Value, pragmas[12].Arguments)
+	}
+}
+
+func TestPragmaSemicolon(t *testing.T) {
+	tests := []struct {
+		input       string
+		expectError bool
+	}{
+		{"new x = 5\nnew y = 6", false},
+		{"#pragma semicolon 1\nnew x = 5;\nnew y = 6;", false},
+		{"#pragma semicolon 1\nnew x = 5\nnew y = 6;", true},
+		{"#pragma semicolon 1\nstock Foo()\n{\n\treturn 1\n}", true},
+		{"#pragma semicolon 1\n#pragma semicolon 0\nnew x = 5\n", false},
+	}
+
+	for _, tt := range tests {
+		_, err := parseProgram(tt.input)
+		if (err != nil) != tt.expectError {
+			t.Errorf("input %q: expected error=%t, got %v", tt.input, tt.expectError, err)
+		}
+	}
+}
+
+func TestYSIKeywordsAreContextual(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"new timer = 0;", "new timer = 0;"},
+		{"new master = 1;", "new master = 1;"},
+		{"new task = timer + 1;", "new task = (timer + 1);"},
+		{"hook = global;", "(hook = global)"},
+		{"yield = 1;", "(yield = 1)"},
+		{"yield++;", "(yield++)"},
+		{"yield[0] = 1;", "((yield[0]) = 1)"},
+		{"foreign(playerid);", "foreign(playerid)"},
+		{"loadtext;", "loadtext"},
+		{"using = inline;", "(using = inline)"},
+		{"hook(playerid) { return 1; }", "hook(playerid) return 1;"},
+	}
+
+	for _, tt := range tests {
+		for _, dialect := range []Dialect{YSI, OpenMP, SAMP} {
+			p := NewWithOptions(lexer.New(tt.input), Options{Dialect: dialect})
+			program, err := p.ParseProgram()
+			if err != nil {
+				t.Fatalf("dialect %d: parse error for %q: %v", dialect, tt.input, err)
+			}
+			if got := program.String(); got != tt.expected {
+				t.Errorf("dialect %d: %q: expected=%q, got=%q", dialect, tt.input, tt.expected, got)
+			}
+		}
+	}
+}
+
+func TestDialects(t *testing.T) {
+	tests := []struct {
+		input    string
+		dialects []Dialect // the dialects that accept input
+	}{
+		{"new x = 1;", []Dialect{YSI, OpenMP, SAMP}},
+		{"new x = __emit(load.pri 0);", []Dialect{YSI, OpenMP}},
+		{"f() { foreach (new i : Player) { } }", []Dialect{YSI}},
+		{"f() { inline Done() { } Call(using inline Done); }", []Dialect{YSI}},
+		{"new Iterator:Admins<10>;", []Dialect{YSI}},
+		{"Text_Send(0, $WELCOME);", []Dialect{YSI}},
+	}
+
+	for _, tt := range tests {
+		for _, dialect := range []Dialect{YSI, OpenMP, SAMP} {
+			accepted := false
+			for _, d := range tt.dialects {
+				accepted = accepted || d == dialect
+			}
+			p := NewWithOptions(lexer.New(tt.input), Options{Dialect: dialect})
+			if _, err := p.ParseProgram(); (err == nil) != accepted {
+				t.Errorf("dialect %d: %q: expected accepted=%t, got err=%v", dialect, tt.input, accepted, err)
+			}
+		}
+	}
+
+	// Without semicolons required, plain Pawn reads "hook Name(...) { }" as
+	// the variable hook followed by a function.
+	for _, input := range []string{"hook OnPlayerConnect(playerid) { }", "timer Save[1000]() { }"} {
+		program, err := NewWithOptions(lexer.New(input), Options{Dialect: YSI}).ParseProgram()
+		if err != nil {
+			t.Fatalf("parse error for %q: %v", input, err)
+		}
+		if _, ok := program.Statements[0].(*ast.ExpressionStatement); ok || len(program.Statements) != 1 {
+			t.Errorf("YSI: %q not parsed as one declaration. got=%s", input, program.String())
+		}
+
+		program, _ = NewWithOptions(lexer.New(input), Options{Dialect: OpenMP}).ParseProgram()
+		if program == nil || len(program.Statements) == 0 {
+			continue
+		}
+		if _, ok := program.Statements[0].(*ast.ExpressionStatement); !ok {
+			t.Errorf("OpenMP: %q parsed as %T", input, program.Statements[0])
+		}
+	}
+
+	// Outside YSI a name starting with '@' is an ordinary identifier.
+	p := NewWithOptions(lexer.New("CallLocalFunction(@Timer);"), Options{Dialect: SAMP})
+	program, err := p.ParseProgram()
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	call := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.CallExpression)
+	if _, ok := call.Arguments[0].(*ast.Identifier); !ok {
+		t.Errorf("@Timer is not an ast.Identifier. got=%T", call.Arguments[0])
+	}
+}
@@ -2,6 +2,7 @@ package parser
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/Tramposo1312/pawn-parser/ast"
 	"github.com/Tramposo1312/pawn-parser/precedence"
@@ -10,33 +11,106 @@ import (
 
 // The entry point for parsing any statement
 func (p *Parser) parseStatement() (ast.Statement, error) {
+	if p.dialect == YSI && p.curTokenIs(token.IDENT) {
+		p.curToken.Type = p.ysiKeyword()
+	}
+
 	switch p.curToken.Type {
 	case token.NEW:
+		if p.dialect == YSI && p.peekTokenIs(token.IDENT) && strings.HasPrefix(p.peekToken.Literal, iteratorTag) {
+			return p.parseIteratorDeclaration()
+		}
 		return p.parseLetStatement()
 	case token.RETURN:
 		return p.parseReturnStatement()
+	case token.SLEEP:
+		return p.parseSleepStatement()
+	case token.EXIT:
+		return p.parseExitStatement()
+	case token.ASSERT:
+		return p.parseAssertStatement()
 	case token.IF:
 		return p.parseIfStatement()
 	case token.WHILE:
 		return p.parseWhileStatement()
 	case token.FOR:
 		return p.parseForStatement()
+	case token.FOREACH:
+		return p.parseForeachStatement()
 	case token.LBRACE:
 		return p.parseBlockStatement()
+	case token.SEMICOLON:
+		return &ast.EmptyStatement{Token: p.curToken}, nil
 	case token.TAG:
 		return p.parseTagDeclaration()
 	case token.ENUM:
 		return p.parseEnumDeclaration()
-	case token.INCLUDE:
+	case token.INCLUDE, token.TRYINCLUDE:
 		return p.parseIncludeDirective()
 	case token.DEFINE:
 		return p.parseDefineDirective()
-	case token.IFDEF:
-		return p.parseIfDefDirective()
+	case token.HASH_IF, token.IFDEF, token.IFNDEF:
+		return p.parseConditionalDirective()
+	case token.ELSEIF, token.HASH_ELSE, token.ENDIF:
+		return nil, fmt.Errorf("%s without #if on line %d", p.curToken.Literal, p.curToken.Line)
+	case token.EMIT:
+		return p.parseEmitDirective()
+	case token.PRAGMA:
+		return p.parsePragmaDirective()
+	case token.ENDINPUT:
+		return &ast.EndInputDirective{Token: p.curToken}, nil
+	case token.UNDEF:
+		return p.parseUndefDirective()
+	case token.ERROR:
+		return p.parseErrorDirective()
+	case token.WARNING:
+		return p.parseWarningDirective()
+	case token.HASH_ASSERT:
+		return p.parseAssertDirective()
+	case token.LINE:
+		return p.parseLineDirective()
+	case token.FILE:
+		return p.parseFileDirective()
+	case token.SECTION:
+		return p.parseSectionDirective()
 	case token.NATIVE:
 		return p.parseNativeFunctionDeclaration()
 	case token.PUBLIC, token.STOCK:
-		return p.parseFunctionDeclaration()
+		decl, err := p.parseFunctionDeclaration()
+		if err != nil {
+			return nil, err
+		}
+		return commandDeclaration(decl), nil
+	case token.STATE:
+		return p.parseStateStatement()
+	case token.HOOK:
+		return p.parseHookDeclaration()
+	case token.TIMER, token.TASK, token.PTASK:
+		return p.parseTimerDeclaration()
+	case token.INLINE:
+		return p.parseInlineDeclaration()
+	case token.FOREIGN, token.GLOBAL, token.REMOTEFUNC:
+		return p.parseRemoteFunctionDeclaration()
+	case token.LOADTEXT:
+		return p.parseLoadTextDeclaration()
+	case token.YIELD:
+		return p.parseYieldStatement()
+	case token.IDENT:
+		switch {
+		case p.dialect != YSI:
+		case p.curToken.Literal == "@yield":
+			return p.parseYieldStatement()
+		case p.curToken.Literal == "@return":
+			return p.parseInlineReturnStatement()
+		}
+		if p.isFunctionDefinitionAhead() {
+			decl, err := p.parsePlainFunctionDeclaration()
+			if err != nil {
+				return nil, err
+			}
+			return commandDeclaration(decl), nil
+		}
+		return p.parseExpressionStatement()
 	default:
 		return p.parseExpressionStatement()
 	}
@@ -63,8 +137,8 @@ func (p *Parser) parseLetStatement() (*ast.LetStatement, error) {
 		return nil, fmt.Errorf("failed to parse expression in let statement: %v", err)
 	}
 
-	if p.peekTokenIs(token.SEMICOLON) {
-		p.nextToken()
+	if err := p.endStatement(); err != nil {
+		return nil, err
 	}
 
 	return stmt, nil
@@ -81,24 +155,118 @@ func (p *Parser) parseReturnStatement() (*ast.ReturnStatement, error) {
 		return nil, fmt.Errorf("failed to parse return value: %v", err)
 	}
 
-	if p.peekTokenIs(token.SEMICOLON) {
-		p.nextToken()
+	if err := p.endStatement(); err != nil {
+		return nil, err
+	}
+
+	return stmt, nil
+}
+
+func (p *Parser) parseSleepStatement() (*ast.SleepStatement, error) {
+	stmt := &ast.SleepStatement{Token: p.curToken}
+
+	var err error
+	stmt.Value, err = p.parseOptionalValue()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse sleep value: %v", err)
+	}
+
+	if err := p.endStatement(); err != nil {
+		return nil, err
+	}
+
+	return stmt, nil
+}
+
+func (p *Parser) parseExitStatement() (*ast.ExitStatement, error) {
+	stmt := &ast.ExitStatement{Token: p.curToken}
+
+	var err error
+	stmt.Value, err = p.parseOptionalValue()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse exit value: %v", err)
+	}
+
+	if err := p.endStatement(); err != nil {
+		return nil, err
+	}
+
+	return stmt, nil
+}
+
+func (p *Parser) parseAssertStatement() (*ast.AssertStatement, error) {
+	stmt := &ast.AssertStatement{Token: p.curToken}
+
+	p.nextToken()
+
+	var err error
+	stmt.Condition, err = p.parseExpression(precedence.LOWEST)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse assert condition: %v", err)
+	}
+
+	if err := p.endStatement(); err != nil {
+		return nil, err
 	}
 
 	return stmt, nil
 }
 
+// parseOptionalValue parses the expression following a keyword such as
+// 'sleep' or 'exit', returning nil when the statement ends immediately.
+func (p *Parser) parseOptionalValue() (ast.Expression, error) {
+	if p.peekTokenIs(token.SEMICOLON) || p.peekTokenIs(token.RBRACE) || p.peekTokenIs(token.EOF) {
+		return nil, nil
+	}
+
+	p.nextToken()
+	return p.parseExpression(precedence.LOWEST)
+}
+
 func (p *Parser) parseExpressionStatement() (*ast.ExpressionStatement, error) {
 	stmt := &ast.ExpressionStatement{Token: p.curToken}
 
 	var err error
-	stmt.Expression, err = p.parseExpression(precedence.LOWEST)
+	stmt.Expression, err = p.parseExpressionSequence()
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse expression in expression statement: %v", err)
 	}
 
-	if p.peekTokenIs(token.SEMICOLON) {
+	if err := p.endStatement(); err != nil {
+		return nil, err
+	}
+
+	return stmt, nil
+}
+
+// parseStateStatement parses a state transition: "state idle;",
+// "state auto:idle;" or "state (condition) idle;".
+func (p *Parser) parseStateStatement() (*ast.StateStatement, error) {
+	stmt := &ast.StateStatement{Token: p.curToken}
+
+	if p.peekTokenIs(token.LPAREN) {
+		p.nextToken()
 		p.nextToken()
+		var err error
+		stmt.Condition, err = p.parseExpression(precedence.LOWEST)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse state condition: %v", err)
+		}
+		if !p.expectPeek(token.RPAREN) {
+			return nil, fmt.Errorf("expected ')' after state condition, got %s", p.peekToken.Type)
+		}
+	}
+
+	if !p.expectPeek(token.IDENT) {
+		return nil, fmt.Errorf("expected state name after 'state', got %s", p.peekToken.Type)
+	}
+
+	automaton, state := splitStateName(p.curToken.Literal)
+	stmt.Automaton = automaton
+	stmt.State = &ast.Identifier{Token: p.curToken, Value: state}
+
+	if err := p.endStatement(); err != nil {
+		return nil, err
 	}
 
 	return stmt, nil
@@ -194,20 +362,24 @@ func (p *Parser) parseForStatement() (*ast.ForStatement, error) {
 	// Parse initialization
 	p.nextToken()
 	var err error
-	stmt.Init, err = p.parseStatement()
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse for init statement: %v", err)
-	}
-
-	if !p.expectPeek(token.SEMICOLON) {
-		return nil, fmt.Errorf("expected ';' after for init statement, got %s", p.peekToken.Type)
+	if !p.curTokenIs(token.SEMICOLON) {
+		stmt.Init, err = p.parseStatement()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse for init statement: %v", err)
+		}
+		// Declarations and expression statements consume their own ';'.
+		if !p.curTokenIs(token.SEMICOLON) && !p.expectPeek(token.SEMICOLON) {
+			return nil, fmt.Errorf("expected ';' after for init statement, got %s", p.peekToken.Type)
+		}
 	}
 
 	// Parse condition
-	p.nextToken()
-	stmt.Condition, err = p.parseExpression(precedence.LOWEST)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse for condition: %v", err)
+	if !p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+		stmt.Condition, err = p.parseExpressionSequence()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse for condition: %v", err)
+		}
 	}
 
 	if !p.expectPeek(token.SEMICOLON) {
@@ -215,10 +387,14 @@ func (p *Parser) parseForStatement() (*ast.ForStatement, error) {
 	}
 
 	// Parse update
-	p.nextToken()
-	stmt.Update, err = p.parseExpressionStatement()
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse for update statement: %v", err)
+	if !p.peekTokenIs(token.RPAREN) {
+		p.nextToken()
+		update := &ast.ExpressionStatement{Token: p.curToken}
+		update.Expression, err = p.parseExpressionSequence()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse for update statement: %v", err)
+		}
+		stmt.Update = update
 	}
 
 	if !p.expectPeek(token.RPAREN) {
@@ -246,8 +422,8 @@ func (p *Parser) parseTagDeclaration() (*ast.TagDeclaration, error) {
 
 	decl.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 
-	if p.peekTokenIs(token.SEMICOLON) {
-		p.nextToken()
+	if err := p.endStatement(); err != nil {
+		return nil, err
 	}
 
 	return decl, nil
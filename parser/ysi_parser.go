@@ -0,0 +1,499 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Tramposo1312/pawn-parser/ast"
+	"github.com/Tramposo1312/pawn-parser/precedence"
+	"github.com/Tramposo1312/pawn-parser/token"
+)
+
+// commandPrefixes are the macros command processors define to declare a
+// command, as in "CMD:kick(playerid, params[])".
+var commandPrefixes = []string{"CMD:", "COMMAND:", "YCMD:"}
+
+// zcmdPrefix starts the name of the public function zcmd's CMD and
+// COMMAND macros expand to.
+const zcmdPrefix = "cmd_"
+
+// iteratorTag starts the name in a y_iterate iterator declaration.
+const iteratorTag = "Iterator:"
+
+// ysiKeyword returns the type of YSI keyword the current identifier is
+// used as, or IDENT where it is an ordinary name. YSI's keywords are
+// macros, not reserved words, so "new timer = 0;" still declares a
+// variable: each is a keyword only when followed by what its construct
+// needs, and yield only when followed by a value or ';'.
+func (p *Parser) ysiKeyword() token.TokenType {
+	keyword := token.LookupYSIKeyword(p.curToken.Literal)
+	switch keyword {
+	case token.HOOK:
+		if p.peekTokenIs(token.IDENT) || p.peekTokenIs(token.FUNCTION) {
+			return keyword
+		}
+	case token.TIMER, token.TASK, token.PTASK, token.INLINE,
+		token.FOREIGN, token.GLOBAL, token.REMOTEFUNC, token.LOADTEXT:
+		if p.peekTokenIs(token.IDENT) {
+			return keyword
+		}
+	case token.FOREACH:
+		if p.peekTokenIs(token.LPAREN) {
+			return keyword
+		}
+	case token.YIELD:
+		if !p.isYieldVariable() {
+			return keyword
+		}
+	}
+	return token.IDENT
+}
+
+// isYieldVariable reports whether the yield starting the current statement
+// is a variable of that name: it is assigned, indexed or incremented.
+// Anything else, such as "yield -1;", "yield (x);" or "yield(x);", yields
+// a value.
+func (p *Parser) isYieldVariable() bool {
+	if p.peekTokenIs(token.LBRACK) || p.peekTokenIs(token.INC) || p.peekTokenIs(token.DEC) {
+		return true
+	}
+	for _, op := range assignmentOperators {
+		if p.peekTokenIs(op) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseHookDeclaration parses a y_hooks "hook Callback(params) { ... }" or
+// "hook function Name(params) { ... }".
+func (p *Parser) parseHookDeclaration() (*ast.HookDeclaration, error) {
+	decl := &ast.HookDeclaration{Token: p.curToken}
+
+	if p.peekTokenIs(token.FUNCTION) {
+		p.nextToken()
+		decl.Function = true
+	}
+
+	if !p.expectPeek(token.IDENT) {
+		return nil, fmt.Errorf("expected name of hooked callback after 'hook', got %s", p.peekToken.Type)
+	}
+	decl.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil, fmt.Errorf("expected ( after hooked callback name, got %s", p.peekToken.Type)
+	}
+	params, err := p.parseFunctionParameters()
+	if err != nil {
+		return nil, err
+	}
+	decl.Parameters = params
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil, fmt.Errorf("expected { to start hook body, got %s", p.peekToken.Type)
+	}
+	decl.Body, err = p.parseBlockStatement()
+	if err != nil {
+		return nil, err
+	}
+	return decl, nil
+}
+
+// parseForeachStatement parses "foreach (new i : Player) { ... }", with or
+// without the 'new'.
+func (p *Parser) parseForeachStatement() (*ast.ForeachStatement, error) {
+	stmt := &ast.ForeachStatement{Token: p.curToken}
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil, fmt.Errorf("expected '(' after 'foreach', got %s", p.peekToken.Type)
+	}
+	if p.peekTokenIs(token.NEW) {
+		p.nextToken()
+		stmt.Declared = true
+	}
+	if !p.expectPeek(token.IDENT) {
+		return nil, fmt.Errorf("expected loop variable in foreach, got %s", p.peekToken.Type)
+	}
+
+	// The lexer reads "i:Player", and the "i:" of "i: Player", as one
+	// identifier, as it would a tag. A tagged variable is only told apart
+	// when a separate ':' follows it.
+	variable := p.curToken
+	if !p.peekTokenIs(token.COLON) {
+		i := strings.LastIndexByte(variable.Literal, ':')
+		if i < 0 {
+			return nil, fmt.Errorf("expected ':' after foreach variable, got %s", p.peekToken.Type)
+		}
+		rest := variable
+		rest.Literal = variable.Literal[i+1:]
+		rest.Column += i + 1
+		variable.Literal = variable.Literal[:i]
+		if rest.Literal == "" {
+			p.nextToken()
+		} else {
+			p.curToken = rest
+		}
+	} else {
+		p.nextToken()
+		p.nextToken()
+	}
+	stmt.Variable = &ast.Identifier{Token: variable, Value: variable.Literal}
+
+	var err error
+	stmt.Iterator, err = p.parseExpression(precedence.LOWEST)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse foreach iterator: %v", err)
+	}
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil, fmt.Errorf("expected ')' after foreach iterator, got %s", p.peekToken.Type)
+	}
+	if !p.expectPeek(token.LBRACE) {
+		return nil, fmt.Errorf("expected '{' to start foreach block, got %s", p.peekToken.Type)
+	}
+	stmt.Body, err = p.parseBlockStatement()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse foreach body: %v", err)
+	}
+	return stmt, nil
+}
+
+// parseIteratorDeclaration parses "new Iterator:Name<size>;" and
+// "new Iterator:Name[count]<size>;". The current token is 'new'.
+func (p *Parser) parseIteratorDeclaration() (*ast.IteratorDeclaration, error) {
+	decl := &ast.IteratorDeclaration{Token: p.curToken}
+
+	p.nextToken()
+	name := p.curToken
+	name.Literal = strings.TrimPrefix(name.Literal, iteratorTag)
+	name.Column += len(iteratorTag)
+	if name.Literal == "" {
+		return nil, fmt.Errorf("expected iterator name after %s", iteratorTag)
+	}
+	decl.Name = &ast.Identifier{Token: name, Value: name.Literal}
+
+	var err error
+	if p.peekTokenIs(token.LBRACK) {
+		p.nextToken()
+		decl.Count, err = p.parseBracketedExpression()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse iterator array size: %v", err)
+		}
+	}
+
+	if !p.expectPeek(token.LT) {
+		return nil, fmt.Errorf("expected < after iterator name, got %s", p.peekToken.Type)
+	}
+	p.nextToken()
+	// Stop before the closing '>'.
+	decl.Size, err = p.parseExpression(precedence.LESSGREATER)
+	if err != nil {
+		return nil, err
+	}
+	if !p.expectPeek(token.GT) {
+		return nil, fmt.Errorf("expected > after iterator size, got %s", p.peekToken.Type)
+	}
+
+	if err := p.endStatement(); err != nil {
+		return nil, err
+	}
+	return decl, nil
+}
+
+// parseTimerDeclaration parses "timer Name[interval](params) { ... }" and
+// the task and ptask forms.
+func (p *Parser) parseTimerDeclaration() (*ast.TimerDeclaration, error) {
+	decl := &ast.TimerDeclaration{Token: p.curToken}
+
+	if !p.expectPeek(token.IDENT) {
+		return nil, fmt.Errorf("expected name after '%s', got %s", decl.Token.Literal, p.peekToken.Type)
+	}
+	decl.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	if !p.expectPeek(token.LBRACK) {
+		return nil, fmt.Errorf("expected [interval] after %s name, got %s", decl.Token.Literal, p.peekToken.Type)
+	}
+	var err error
+	decl.Interval, err = p.parseBracketedExpression()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s interval: %v", decl.Token.Literal, err)
+	}
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil, fmt.Errorf("expected ( after %s interval, got %s", decl.Token.Literal, p.peekToken.Type)
+	}
+	decl.Parameters, err = p.parseFunctionParameters()
+	if err != nil {
+		return nil, err
+	}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil, fmt.Errorf("expected { to start %s body, got %s", decl.Token.Literal, p.peekToken.Type)
+	}
+	decl.Body, err = p.parseBlockStatement()
+	if err != nil {
+		return nil, err
+	}
+	return decl, nil
+}
+
+// parseTimerCallExpression parses "defer Name(args)" or "repeat
+// Name(args)", optionally with a [delay] after the name.
+func (p *Parser) parseTimerCallExpression() (ast.Expression, error) {
+	exp := &ast.TimerCallExpression{Token: p.curToken}
+
+	p.nextToken()
+	exp.Timer = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	if p.peekTokenIs(token.LBRACK) {
+		p.nextToken()
+		var err error
+		exp.Delay, err = p.parseBracketedExpression()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s delay: %v", exp.Token.Literal, err)
+		}
+	}
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil, fmt.Errorf("expected ( after timer name in %s, got %s", exp.Token.Literal, p.peekToken.Type)
+	}
+	args, err := p.parseExpressionList(token.RPAREN)
+	if err != nil {
+		return nil, err
+	}
+	exp.Arguments = args
+	return exp, nil
+}
+
+// parseStopExpression parses "stop timer".
+func (p *Parser) parseStopExpression() (ast.Expression, error) {
+	exp := &ast.StopExpression{Token: p.curToken}
+
+	p.nextToken()
+	var err error
+	exp.Timer, err = p.parseExpression(precedence.PREFIX)
+	if err != nil {
+		return nil, err
+	}
+	return exp, nil
+}
+
+// parseBracketedExpression parses the expression between the current '['
+// and its ']'.
+func (p *Parser) parseBracketedExpression() (ast.Expression, error) {
+	p.nextToken()
+	exp, err := p.parseExpression(precedence.LOWEST)
+	if err != nil {
+		return nil, err
+	}
+	if !p.expectPeek(token.RBRACK) {
+		return nil, fmt.Errorf("expected ], got %s", p.peekToken.Type)
+	}
+	return exp, nil
+}
+
+// parseInlineDeclaration parses "inline Name(params) { ... }" inside a
+// function body.
+func (p *Parser) parseInlineDeclaration() (*ast.InlineDeclaration, error) {
+	decl := &ast.InlineDeclaration{Token: p.curToken}
+
+	if !p.expectPeek(token.IDENT) {
+		return nil, fmt.Errorf("expected name after 'inline', got %s", p.peekToken.Type)
+	}
+	decl.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil, fmt.Errorf("expected ( after inline function name, got %s", p.peekToken.Type)
+	}
+	var err error
+	decl.Parameters, err = p.parseFunctionParameters()
+	if err != nil {
+		return nil, err
+	}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil, fmt.Errorf("expected { to start inline function body, got %s", p.peekToken.Type)
+	}
+	decl.Body, err = p.parseBlockStatement()
+	if err != nil {
+		return nil, err
+	}
+	return decl, nil
+}
+
+// parseUsingExpression parses "using inline Name", "using public Name" and
+// "using callback Name".
+func (p *Parser) parseUsingExpression() (ast.Expression, error) {
+	exp := &ast.UsingExpression{Token: p.curToken}
+
+	p.nextToken()
+	switch {
+	case p.curToken.Literal == "inline", p.curTokenIs(token.PUBLIC), p.curToken.Literal == "callback":
+		exp.Kind = p.curToken.Literal
+	default:
+		return nil, fmt.Errorf("expected inline, public or callback after 'using', got %s", p.curToken.Literal)
+	}
+
+	if !p.expectPeek(token.IDENT) {
+		return nil, fmt.Errorf("expected function name after 'using %s', got %s", exp.Kind, p.peekToken.Type)
+	}
+	exp.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	return exp, nil
+}
+
+// parseRemoteFunctionDeclaration parses "foreign Tag:Name(params);" and
+// the "global" and "remotefunc" definitions, which have a body instead.
+func (p *Parser) parseRemoteFunctionDeclaration() (*ast.RemoteFunctionDeclaration, error) {
+	decl := &ast.RemoteFunctionDeclaration{Token: p.curToken}
+
+	if !p.expectPeek(token.IDENT) {
+		return nil, fmt.Errorf("expected function name after '%s', got %s", decl.Token.Literal, p.peekToken.Type)
+	}
+	// The lexer reads the return tag and the name as one identifier.
+	name := p.curToken
+	if i := strings.LastIndexByte(name.Literal, ':'); i >= 0 {
+		decl.Tag = name.Literal[:i]
+		name.Literal = name.Literal[i+1:]
+		name.Column += i + 1
+	}
+	if name.Literal == "" {
+		return nil, fmt.Errorf("expected function name after %s:", decl.Tag)
+	}
+	decl.Name = &ast.Identifier{Token: name, Value: name.Literal}
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil, fmt.Errorf("expected ( after %s function name, got %s", decl.Token.Literal, p.peekToken.Type)
+	}
+	var err error
+	decl.Parameters, err = p.parseFunctionParameters()
+	if err != nil {
+		return nil, err
+	}
+
+	if decl.Token.Type == token.FOREIGN {
+		if err := p.endStatement(); err != nil {
+			return nil, err
+		}
+		return decl, nil
+	}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil, fmt.Errorf("expected { to start %s function body, got %s", decl.Token.Literal, p.peekToken.Type)
+	}
+	decl.Body, err = p.parseBlockStatement()
+	if err != nil {
+		return nil, err
+	}
+	return decl, nil
+}
+
+// parseLoadTextDeclaration parses "loadtext file[section], ...;".
+func (p *Parser) parseLoadTextDeclaration() (*ast.LoadTextDeclaration, error) {
+	decl := &ast.LoadTextDeclaration{Token: p.curToken}
+
+	for {
+		if !p.expectPeek(token.IDENT) {
+			return nil, fmt.Errorf("expected text file name in loadtext, got %s", p.peekToken.Type)
+		}
+		section := &ast.TextSection{Token: p.curToken, File: p.curToken.Literal}
+		if !p.expectPeek(token.LBRACK) {
+			return nil, fmt.Errorf("expected [section] after %s, got %s", section.File, p.peekToken.Type)
+		}
+		if !p.expectPeek(token.IDENT) {
+			return nil, fmt.Errorf("expected section name in %s[], got %s", section.File, p.peekToken.Type)
+		}
+		section.Section = p.curToken.Literal
+		if !p.expectPeek(token.RBRACK) {
+			return nil, fmt.Errorf("expected ] after section name, got %s", p.peekToken.Type)
+		}
+		decl.Sections = append(decl.Sections, section)
+
+		if !p.peekTokenIs(token.COMMA) {
+			break
+		}
+		p.nextToken()
+	}
+
+	if err := p.endStatement(); err != nil {
+		return nil, err
+	}
+	return decl, nil
+}
+
+func (p *Parser) parseTextIdentifier() (ast.Expression, error) {
+	return &ast.TextIdentifier{Token: p.curToken, Name: strings.TrimPrefix(p.curToken.Literal, "$")}, nil
+}
+
+// parseYieldStatement parses "yield value;" and its "@yield" spelling.
+func (p *Parser) parseYieldStatement() (*ast.YieldStatement, error) {
+	stmt := &ast.YieldStatement{Token: p.curToken}
+
+	var err error
+	stmt.Value, err = p.parseOptionalValue()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s value: %v", stmt.Token.Literal, err)
+	}
+
+	if err := p.endStatement(); err != nil {
+		return nil, err
+	}
+	return stmt, nil
+}
+
+func (p *Parser) parseInlineReturnStatement() (*ast.InlineReturnStatement, error) {
+	stmt := &ast.InlineReturnStatement{Token: p.curToken}
+
+	var err error
+	stmt.Value, err = p.parseOptionalValue()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse @return value: %v", err)
+	}
+
+	if err := p.endStatement(); err != nil {
+		return nil, err
+	}
+	return stmt, nil
+}
+
+// parseVarArgsExpression parses y_va's "___(n)" or a bare "___".
+func (p *Parser) parseVarArgsExpression() (ast.Expression, error) {
+	va := &ast.VarArgsExpression{Token: p.curToken}
+	if !p.peekTokenIs(token.LPAREN) {
+		return va, nil
+	}
+	p.nextToken()
+	p.nextToken()
+
+	var err error
+	va.Start, err = p.parseExpression(precedence.LOWEST)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ___ argument: %v", err)
+	}
+	if !p.expectPeek(token.RPAREN) {
+		return nil, fmt.Errorf("expected ) after ___ argument, got %s", p.peekToken.Type)
+	}
+	return va, nil
+}
+
+// commandDeclaration returns decl as a CommandDeclaration if it declares a
+// chat command, and decl itself otherwise.
+func commandDeclaration(decl *ast.FunctionDeclaration) ast.Statement {
+	name := decl.Name.Value
+	for _, prefix := range commandPrefixes {
+		if strings.HasPrefix(name, prefix) && len(name) > len(prefix) {
+			return &ast.CommandDeclaration{
+				FunctionDeclaration: decl,
+				Processor:           strings.TrimSuffix(prefix, ":"),
+				Command:             name[len(prefix):],
+			}
+		}
+	}
+	if decl.Token.Type == token.PUBLIC && strings.HasPrefix(name, zcmdPrefix) && len(name) > len(zcmdPrefix) {
+		return &ast.CommandDeclaration{
+			FunctionDeclaration: decl,
+			Processor:           "zcmd",
+			Command:             name[len(zcmdPrefix):],
+		}
+	}
+	return decl
+}
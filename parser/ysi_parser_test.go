@@ -0,0 +1,702 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/Tramposo1312/pawn-parser/ast"
+	"github.com/Tramposo1312/pawn-parser/lexer"
+)
+
+func TestHookDeclarations(t *testing.T) {
+	input := `
+hook OnPlayerConnect(playerid) { return 1; }
+hook OnGameModeInit() { return 1; }
+hook function SetPlayerHealth(playerid, Float:health) { return 0; }
+public OnPlayerConnect(playerid) { return 1; }
+`
+
+	program, err := parseProgram(input)
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+
+	tests := []struct {
+		expectedName     string
+		expectedFunction bool
+		expectedParams   []string
+	}{
+		{"OnPlayerConnect", false, []string{"playerid"}},
+		{"OnGameModeInit", false, []string{}},
+		{"SetPlayerHealth", true, []string{"playerid", "Float:health"}},
+	}
+
+	if len(program.Statements) != len(tests)+1 {
+		t.Fatalf("program.Statements does not contain %d statements. got=%d",
+			len(tests)+1, len(program.Statements))
+	}
+
+	for i, tt := range tests {
+		hook, ok := program.Statements[i].(*ast.HookDeclaration)
+		if !ok {
+			t.Fatalf("program.Statements[%d] is not ast.HookDeclaration. got=%T",
+				i, program.Statements[i])
+		}
+		if hook.Name.Value != tt.expectedName {
+			t.Errorf("hook.Name not %s. got=%s", tt.expectedName, hook.Name.Value)
+		}
+		if hook.Function != tt.expectedFunction {
+			t.Errorf("hook.Function not %t", tt.expectedFunction)
+		}
+		if len(hook.Parameters) != len(tt.expectedParams) {
+			t.Fatalf("hook has wrong parameters. want=%v, got=%d", tt.expectedParams, len(hook.Parameters))
+		}
+		for j, param := range tt.expectedParams {
+			if hook.Parameters[j].Value != param {
+				t.Errorf("parameter %d not %s. got=%s", j, param, hook.Parameters[j].Value)
+			}
+		}
+		if hook.Body == nil || len(hook.Body.Statements) != 1 {
+			t.Errorf("hook body not parsed. got=%v", hook.Body)
+		}
+	}
+
+	if _, ok := program.Statements[3].(*ast.FunctionDeclaration); !ok {
+		t.Errorf("program.Statements[3] is not ast.FunctionDeclaration. got=%T", program.Statements[3])
+	}
+
+	expected := "hook function SetPlayerHealth(playerid, Float:health) return 0;"
+	if got := program.Statements[2].String(); got != expected {
+		t.Errorf("String() wrong.\nexpected=%q\ngot=     %q", expected, got)
+	}
+}
+
+func TestHookAsFunctionName(t *testing.T) {
+	// Without a callback name, hook is an ordinary function name.
+	program, err := parseProgram("hook (playerid) { }")
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	decl, ok := program.Statements[0].(*ast.FunctionDeclaration)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.FunctionDeclaration. got=%T", program.Statements[0])
+	}
+	if decl.Name.Value != "hook" || len(decl.Parameters) != 1 {
+		t.Errorf("decl wrong. got=%s", decl.String())
+	}
+}
+
+func TestHookDeclarationErrors(t *testing.T) {
+	tests := []string{
+		"hook OnPlayerConnect { }",
+		"hook OnPlayerConnect(playerid);",
+		"hook function (x) { }",
+	}
+
+	for _, input := range tests {
+		if _, err := parseProgram(input); err == nil {
+			t.Errorf("expected an error for %q", input)
+		}
+	}
+}
+
+func TestForeachStatements(t *testing.T) {
+	tests := []struct {
+		input            string
+		expectedDeclared bool
+		expectedVariable string
+		expectedIterator string
+	}{
+		{"foreach (new i : Player) { }", true, "i", "Player"},
+		{"foreach (new v : Vehicle) { }", true, "v", "Vehicle"},
+		{"foreach (i : Player) { }", false, "i", "Player"},
+		{"foreach (new i:Player) { }", true, "i", "Player"},
+		{"foreach (new i: Player) { }", true, "i", "Player"},
+		{"foreach (new v : PlayerVehicles[playerid]) { }", true, "v", "(PlayerVehicles[playerid])"},
+		{"foreach (new v:PlayerVehicles[playerid]) { }", true, "v", "(PlayerVehicles[playerid])"},
+		{"foreach (new i : Range(0, 10)) { }", true, "i", "Range(0, 10)"},
+		{"foreach (new Float:f : Floats) { }", true, "Float:f", "Floats"},
+	}
+
+	for _, tt := range tests {
+		program, err := parseProgram(tt.input)
+		if err != nil {
+			t.Fatalf("parse error for %q: %v", tt.input, err)
+		}
+		if len(program.Statements) != 1 {
+			t.Fatalf("%q: program.Statements does not contain 1 statement. got=%d",
+				tt.input, len(program.Statements))
+		}
+		stmt, ok := program.Statements[0].(*ast.ForeachStatement)
+		if !ok {
+			t.Fatalf("program.Statements[0] is not ast.ForeachStatement. got=%T",
+				program.Statements[0])
+		}
+		if stmt.Declared != tt.expectedDeclared {
+			t.Errorf("%q: stmt.Declared not %t", tt.input, tt.expectedDeclared)
+		}
+		if stmt.Variable.Value != tt.expectedVariable {
+			t.Errorf("%q: stmt.Variable not %s. got=%s", tt.input, tt.expectedVariable, stmt.Variable.Value)
+		}
+		if got := stmt.Iterator.String(); got != tt.expectedIterator {
+			t.Errorf("%q: stmt.Iterator not %s. got=%s", tt.input, tt.expectedIterator, got)
+		}
+	}
+}
+
+func TestForeachInFunction(t *testing.T) {
+	input := `
+public OnGameModeExit()
+{
+	foreach (new i : Player)
+	{
+		Kick(i);
+	}
+	return 1;
+}
+`
+	program, err := parseProgram(input)
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	decl := program.Statements[0].(*ast.FunctionDeclaration)
+	loop, ok := decl.Body.Statements[0].(*ast.ForeachStatement)
+	if !ok {
+		t.Fatalf("first statement is not ast.ForeachStatement. got=%T", decl.Body.Statements[0])
+	}
+	if len(loop.Body.Statements) != 1 {
+		t.Errorf("loop body does not contain 1 statement. got=%d", len(loop.Body.Statements))
+	}
+}
+
+func TestIteratorDeclarations(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedName  string
+		expectedCount string
+		expectedSize  string
+	}{
+		{"new Iterator:Admins<MAX_PLAYERS>;", "Admins", "", "MAX_PLAYERS"},
+		{"new Iterator:Slots<MAX_SLOTS + 1>;", "Slots", "", "(MAX_SLOTS + 1)"},
+		{"new Iterator:PlayerVehicles[MAX_PLAYERS]<MAX_VEHICLES>;", "PlayerVehicles", "MAX_PLAYERS", "MAX_VEHICLES"},
+	}
+
+	program, err := parseProgram(tests[2].input)
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	if got := program.Statements[0].String(); got != tests[2].input {
+		t.Errorf("String() not %q. got=%q", tests[2].input, got)
+	}
+
+	for _, tt := range tests {
+		program, err := parseProgram(tt.input)
+		if err != nil {
+			t.Fatalf("parse error for %q: %v", tt.input, err)
+		}
+		decl, ok := program.Statements[0].(*ast.IteratorDeclaration)
+		if !ok {
+			t.Fatalf("program.Statements[0] is not ast.IteratorDeclaration. got=%T",
+				program.Statements[0])
+		}
+		if decl.Name.Value != tt.expectedName {
+			t.Errorf("decl.Name not %s. got=%s", tt.expectedName, decl.Name.Value)
+		}
+		count := ""
+		if decl.Count != nil {
+			count = decl.Count.String()
+		}
+		if count != tt.expectedCount {
+			t.Errorf("decl.Count not %q. got=%q", tt.expectedCount, count)
+		}
+		if decl.Size.String() != tt.expectedSize {
+			t.Errorf("decl.Size not %s. got=%s", tt.expectedSize, decl.Size.String())
+		}
+	}
+}
+
+func TestForeachErrors(t *testing.T) {
+	tests := []string{
+		"foreach (new i) { }",
+		"foreach (new i : ) { }",
+		"foreach new i : Player { }",
+		"foreach (new i : Player) Kick(i);",
+		"new Iterator:Admins;",
+		"new Iterator:Admins<MAX_PLAYERS;",
+	}
+
+	for _, input := range tests {
+		if _, err := parseProgram(input); err == nil {
+			t.Errorf("expected an error for %q", input)
+		}
+	}
+}
+
+func TestTimerDeclarations(t *testing.T) {
+	input := `
+timer SaveAll[60000]() { return 1; }
+task Tick[1000]() { }
+ptask PlayerTick[500](playerid) { }
+timer Respawn[MINUTES * 5](playerid, vehicleid) { }
+`
+
+	program, err := parseProgram(input)
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+
+	tests := []struct {
+		expectedKind     string
+		expectedName     string
+		expectedInterval string
+		expectedParams   int
+	}{
+		{"timer", "SaveAll", "60000", 0},
+		{"task", "Tick", "1000", 0},
+		{"ptask", "PlayerTick", "500", 1},
+		{"timer", "Respawn", "(MINUTES * 5)", 2},
+	}
+
+	if len(program.Statements) != len(tests) {
+		t.Fatalf("program.Statements does not contain %d statements. got=%d",
+			len(tests), len(program.Statements))
+	}
+
+	for i, tt := range tests {
+		decl, ok := program.Statements[i].(*ast.TimerDeclaration)
+		if !ok {
+			t.Fatalf("program.Statements[%d] is not ast.TimerDeclaration. got=%T",
+				i, program.Statements[i])
+		}
+		if decl.TokenLiteral() != tt.expectedKind {
+			t.Errorf("decl.TokenLiteral() not %s. got=%s", tt.expectedKind, decl.TokenLiteral())
+		}
+		if decl.Name.Value != tt.expectedName {
+			t.Errorf("decl.Name not %s. got=%s", tt.expectedName, decl.Name.Value)
+		}
+		if decl.Interval.String() != tt.expectedInterval {
+			t.Errorf("decl.Interval not %s. got=%s", tt.expectedInterval, decl.Interval.String())
+		}
+		if len(decl.Parameters) != tt.expectedParams {
+			t.Errorf("decl.Parameters has %d parameters. expected %d", len(decl.Parameters), tt.expectedParams)
+		}
+	}
+}
+
+func TestTimerCalls(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"defer SaveAll();", "defer SaveAll()"},
+		{"defer Respawn[5000](playerid, 1);", "defer Respawn[5000](playerid, 1)"},
+		{"repeat Tick();", "repeat Tick()"},
+		{"new Timer:t = repeat Tick();", "new Timer:t = repeat Tick();"},
+		{"stop t;", "stop t"},
+		{"stop PlayerTimers[playerid];", "stop (PlayerTimers[playerid])"},
+		{"defer = 1;", "(defer = 1)"},
+	}
+
+	for _, tt := range tests {
+		program, err := parseProgram(tt.input)
+		if err != nil {
+			t.Fatalf("parse error for %q: %v", tt.input, err)
+		}
+		if len(program.Statements) != 1 {
+			t.Fatalf("%q: program.Statements does not contain 1 statement. got=%d",
+				tt.input, len(program.Statements))
+		}
+		if got := program.Statements[0].String(); got != tt.expected {
+			t.Errorf("%q: expected=%q, got=%q", tt.input, tt.expected, got)
+		}
+	}
+
+	program, err := parseProgram("defer Respawn[5000](playerid);")
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	call, ok := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.TimerCallExpression)
+	if !ok {
+		t.Fatalf("expression is not ast.TimerCallExpression. got=%T",
+			program.Statements[0].(*ast.ExpressionStatement).Expression)
+	}
+	if call.Timer.Value != "Respawn" || call.Delay.String() != "5000" || len(call.Arguments) != 1 {
+		t.Errorf("wrong timer call. got=%s", call.String())
+	}
+}
+
+func TestInlineFunctions(t *testing.T) {
+	input := `
+LoadAccount(playerid)
+{
+	new attempts = 0;
+	inline OnDataLoaded(string:name[], level)
+	{
+		attempts++;
+		return level;
+	}
+	MySQL_TQueryInline(handle, using inline OnDataLoaded, "SELECT 1");
+	Dialog_ShowCallback(playerid, using public OnDialog, DIALOG_STYLE_MSGBOX);
+	Timer_Call(using callback OnTimer);
+	return 1;
+}
+`
+	program, err := parseProgram(input)
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+
+	decl := program.Statements[0].(*ast.FunctionDeclaration)
+	if len(decl.Body.Statements) != 6 {
+		t.Fatalf("function body does not contain 6 statements. got=%d", len(decl.Body.Statements))
+	}
+
+	inline, ok := decl.Body.Statements[1].(*ast.InlineDeclaration)
+	if !ok {
+		t.Fatalf("statement 1 is not ast.InlineDeclaration. got=%T", decl.Body.Statements[1])
+	}
+	if inline.Name.Value != "OnDataLoaded" {
+		t.Errorf("inline.Name not OnDataLoaded. got=%s", inline.Name.Value)
+	}
+	if len(inline.Parameters) != 2 || inline.Parameters[0].Value != "string:name[]" {
+		t.Errorf("inline parameters wrong. got=%v", inline.Parameters)
+	}
+	if len(inline.Body.Statements) != 2 {
+		t.Errorf("inline body does not contain 2 statements. got=%d", len(inline.Body.Statements))
+	}
+
+	kinds := []string{"inline", "public", "callback"}
+	names := []string{"OnDataLoaded", "OnDialog", "OnTimer"}
+	for i, kind := range kinds {
+		call := decl.Body.Statements[2+i].(*ast.ExpressionStatement).Expression.(*ast.CallExpression)
+		var using *ast.UsingExpression
+		for _, arg := range call.Arguments {
+			if u, ok := arg.(*ast.UsingExpression); ok {
+				using = u
+			}
+		}
+		if using == nil {
+			t.Fatalf("no using argument in %s", call.String())
+		}
+		if using.Kind != kind || using.Name.Value != names[i] {
+			t.Errorf("using wrong. expected=using %s %s, got=%s", kind, names[i], using.String())
+		}
+	}
+}
+
+func TestUsingErrors(t *testing.T) {
+	tests := []string{
+		"Call(using OnX);",
+		"Call(using inline);",
+		"Call(using stock OnX);",
+		"f() { inline OnX { } }",
+	}
+
+	for _, input := range tests {
+		if _, err := parseProgram(input); err == nil {
+			t.Errorf("expected an error for %q", input)
+		}
+	}
+}
+
+func TestRemoteFunctionDeclarations(t *testing.T) {
+	input := `
+foreign void:KickPlayer(playerid, string:reason[]);
+foreign GetScore(playerid);
+global void:KickPlayer(playerid, string:reason[]) { Kick(playerid); }
+remotefunc string:GetName(playerid) { return 0; }
+`
+	program, err := parseProgram(input)
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+
+	tests := []struct {
+		expectedSpecifier string
+		expectedTag       string
+		expectedName      string
+		expectedSignature string
+		expectedBody      bool
+	}{
+		{"foreign", "void", "KickPlayer", "void:KickPlayer(playerid, string:reason[])", false},
+		{"foreign", "", "GetScore", "GetScore(playerid)", false},
+		{"global", "void", "KickPlayer", "void:KickPlayer(playerid, string:reason[])", true},
+		{"remotefunc", "string", "GetName", "string:GetName(playerid)", true},
+	}
+
+	if len(program.Statements) != len(tests) {
+		t.Fatalf("program.Statements does not contain %d statements. got=%d",
+			len(tests), len(program.Statements))
+	}
+
+	for i, tt := range tests {
+		decl, ok := program.Statements[i].(*ast.RemoteFunctionDeclaration)
+		if !ok {
+			t.Fatalf("program.Statements[%d] is not ast.RemoteFunctionDeclaration. got=%T",
+				i, program.Statements[i])
+		}
+		if decl.TokenLiteral() != tt.expectedSpecifier {
+			t.Errorf("decl.TokenLiteral() not %s. got=%s", tt.expectedSpecifier, decl.TokenLiteral())
+		}
+		if decl.Tag != tt.expectedTag || decl.Name.Value != tt.expectedName {
+			t.Errorf("decl name wrong. expected=%s:%s, got=%s:%s", tt.expectedTag, tt.expectedName, decl.Tag, decl.Name.Value)
+		}
+		if decl.Signature() != tt.expectedSignature {
+			t.Errorf("decl.Signature() not %q. got=%q", tt.expectedSignature, decl.Signature())
+		}
+		if (decl.Body != nil) != tt.expectedBody {
+			t.Errorf("decl.Body present=%t. expected %t", decl.Body != nil, tt.expectedBody)
+		}
+	}
+
+	// The foreign declaration and global definition of a function agree.
+	foreign := program.Statements[0].(*ast.RemoteFunctionDeclaration)
+	global := program.Statements[2].(*ast.RemoteFunctionDeclaration)
+	if foreign.Signature() != global.Signature() {
+		t.Errorf("signatures differ: %q and %q", foreign.Signature(), global.Signature())
+	}
+}
+
+func TestForeignAsFunctionName(t *testing.T) {
+	// Without a function name, foreign is an ordinary function being called.
+	program, err := parseProgram("foreign (playerid);")
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.ExpressionStatement. got=%T", program.Statements[0])
+	}
+	call, ok := stmt.Expression.(*ast.CallExpression)
+	if !ok {
+		t.Fatalf("stmt.Expression is not ast.CallExpression. got=%T", stmt.Expression)
+	}
+	if ident, ok := call.Function.(*ast.Identifier); !ok || ident.Value != "foreign" {
+		t.Errorf("call.Function is not identifier foreign. got=%s", call.Function.String())
+	}
+}
+
+func TestRemoteFunctionErrors(t *testing.T) {
+	tests := []string{
+		"foreign void:(playerid);",
+		"global Kick(playerid);",
+		"remotefunc Kick;",
+	}
+
+	for _, input := range tests {
+		if _, err := parseProgram(input); err == nil {
+			t.Errorf("expected an error for %q", input)
+		}
+	}
+}
+
+func TestTextDeclarations(t *testing.T) {
+	input := `
+ShowWelcome(playerid)
+{
+	loadtext core[messages], core[errors];
+	Text_Send(playerid, $WELCOME, GetPlayerScore(playerid));
+}
+`
+	program, err := parseProgram(input)
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+
+	decl := program.Statements[0].(*ast.FunctionDeclaration)
+	load, ok := decl.Body.Statements[0].(*ast.LoadTextDeclaration)
+	if !ok {
+		t.Fatalf("Statements[0] is not ast.LoadTextDeclaration. got=%T", decl.Body.Statements[0])
+	}
+	if load.String() != "loadtext core[messages], core[errors];" {
+		t.Errorf("load.String() wrong. got=%q", load.String())
+	}
+	if len(load.Sections) != 2 || load.Sections[1].File != "core" || load.Sections[1].Section != "errors" {
+		t.Errorf("sections wrong. got=%+v", load.Sections)
+	}
+
+	call := decl.Body.Statements[1].(*ast.ExpressionStatement).Expression.(*ast.CallExpression)
+	text, ok := call.Arguments[1].(*ast.TextIdentifier)
+	if !ok {
+		t.Fatalf("argument is not ast.TextIdentifier. got=%T", call.Arguments[1])
+	}
+	if text.Name != "WELCOME" || text.String() != "$WELCOME" {
+		t.Errorf("text identifier wrong. got=%s (%s)", text.Name, text.String())
+	}
+}
+
+func TestLoadTextAsIdentifier(t *testing.T) {
+	// Without a file name, loadtext is an ordinary name.
+	program, err := parseProgram("loadtext;")
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.ExpressionStatement. got=%T", program.Statements[0])
+	}
+	if ident, ok := stmt.Expression.(*ast.Identifier); !ok || ident.Value != "loadtext" {
+		t.Errorf("stmt.Expression is not identifier loadtext. got=%T %s", stmt.Expression, stmt.Expression.String())
+	}
+}
+
+func TestTextDeclarationErrors(t *testing.T) {
+	tests := []string{
+		"loadtext core;",
+		"loadtext core[];",
+		"loadtext core[messages],;",
+	}
+
+	for _, input := range tests {
+		if _, err := parseProgram(input); err == nil {
+			t.Errorf("expected an error for %q", input)
+		}
+	}
+}
+
+func TestYieldAndSpecialSyntax(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"yield 1;", "yield 1;"},
+		{"yield;", "yield;"},
+		{"yield -1;", "yield (-1);"},
+		{"yield (x);", "yield x;"},
+		{"yield(x);", "yield x;"},
+		{"yield x + 1;", "yield (x + 1);"},
+		{"@yield 2;", "@yield 2;"},
+		{"@return count + 1;", "@return (count + 1);"},
+		{"@return;", "@return;"},
+		{"printf(fmt, ___(1));", "printf(fmt, ___(1))"},
+		{"va_printf(fmt, ___);", "va_printf(fmt, ___)"},
+		{"CallLocalFunction(@Timer, 1);", "CallLocalFunction(@Timer, 1)"},
+		{"format(str, 256, YSI_FORMAT, ___(2));", "format(str, 256, YSI_FORMAT, ___(2))"},
+	}
+
+	for _, tt := range tests {
+		program, err := parseProgram(tt.input)
+		if err != nil {
+			t.Fatalf("parse error for %q: %v", tt.input, err)
+		}
+		if len(program.Statements) != 1 {
+			t.Fatalf("%q: program.Statements does not contain 1 statement. got=%d",
+				tt.input, len(program.Statements))
+		}
+		if got := program.Statements[0].String(); got != tt.expected {
+			t.Errorf("%q: expected=%q, got=%q", tt.input, tt.expected, got)
+		}
+	}
+
+	program, err := parseProgram("@return Func(@Timer, ___(3));")
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	ret, ok := program.Statements[0].(*ast.InlineReturnStatement)
+	if !ok {
+		t.Fatalf("not ast.InlineReturnStatement. got=%T", program.Statements[0])
+	}
+	call := ret.Value.(*ast.CallExpression)
+	if special, ok := call.Arguments[0].(*ast.SpecialIdentifier); !ok || special.Name != "Timer" {
+		t.Errorf("argument 0 is not SpecialIdentifier Timer. got=%#v", call.Arguments[0])
+	}
+	if va, ok := call.Arguments[1].(*ast.VarArgsExpression); !ok || va.Start.String() != "3" {
+		t.Errorf("argument 1 is not ___(3). got=%#v", call.Arguments[1])
+	}
+
+	program, err = parseProgram("yield 1;")
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	if _, ok := program.Statements[0].(*ast.YieldStatement); !ok {
+		t.Errorf("not ast.YieldStatement. got=%T", program.Statements[0])
+	}
+
+	// Spacing does not matter: both spellings yield in YSI and call a
+	// function named yield elsewhere.
+	for _, input := range []string{"yield(1);", "yield (1);"} {
+		for dialect, expected := range map[Dialect]string{YSI: "yield 1;", SAMP: "yield(1)"} {
+			program, err := NewWithOptions(lexer.New(input), Options{Dialect: dialect}).ParseProgram()
+			if err != nil {
+				t.Fatalf("dialect %d: parse error for %q: %v", dialect, input, err)
+			}
+			if got := program.String(); got != expected {
+				t.Errorf("dialect %d: %q: expected=%q, got=%q", dialect, input, expected, got)
+			}
+		}
+	}
+}
+
+func TestYieldErrors(t *testing.T) {
+	tests := []string{
+		"yield );",
+		"@return );",
+		"f(___(1);",
+		"f(___());",
+	}
+
+	for _, input := range tests {
+		if _, err := parseProgram(input); err == nil {
+			t.Errorf("expected an error for %q", input)
+		}
+	}
+}
+
+func TestCommandDeclarations(t *testing.T) {
+	input := `
+CMD:kick(playerid, params[]) { return 1; }
+YCMD:help(playerid, params[], help) { return 1; }
+COMMAND:ban(playerid, params[]) { return 1; }
+public cmd_mute(playerid, params[]) { return 1; }
+public OnPlayerCommandText(playerid, cmdtext[]) { return 0; }
+stock cmd_helper() { return 1; }
+`
+	program, err := parseProgram(input)
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+
+	tests := []struct {
+		index             int
+		expectedProcessor string
+		expectedCommand   string
+		expectedParams    int
+	}{
+		{0, "CMD", "kick", 2},
+		{1, "YCMD", "help", 3},
+		{2, "COMMAND", "ban", 2},
+		{3, "zcmd", "mute", 2},
+	}
+
+	for _, tt := range tests {
+		decl, ok := program.Statements[tt.index].(*ast.CommandDeclaration)
+		if !ok {
+			t.Fatalf("program.Statements[%d] is not ast.CommandDeclaration. got=%T",
+				tt.index, program.Statements[tt.index])
+		}
+		if decl.Processor != tt.expectedProcessor || decl.Command != tt.expectedCommand {
+			t.Errorf("statements[%d]: expected %s %s, got %s %s", tt.index,
+				tt.expectedProcessor, tt.expectedCommand, decl.Processor, decl.Command)
+		}
+		if len(decl.Parameters) != tt.expectedParams {
+			t.Errorf("statements[%d]: expected %d parameters, got %d", tt.index,
+				tt.expectedParams, len(decl.Parameters))
+		}
+	}
+
+	// Callbacks, and cmd_ functions that are not public, are ordinary functions.
+	for _, i := range []int{4, 5} {
+		if _, ok := program.Statements[i].(*ast.FunctionDeclaration); !ok {
+			t.Errorf("program.Statements[%d] is not ast.FunctionDeclaration. got=%T",
+				i, program.Statements[i])
+		}
+	}
+
+	// The position comes from the embedded function declaration.
+	if tok, ok := ast.NodeToken(program.Statements[1]); !ok || tok.Line != 3 || tok.Literal != "YCMD:help" {
+		t.Errorf("NodeToken wrong. got=%+v, %t", tok, ok)
+	}
+
+	if got := program.Statements[0].String(); got != "CMD:kick(playerid, params[]) return 1;" {
+		t.Errorf("String() wrong. got=%q", got)
+	}
+}
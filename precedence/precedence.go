@@ -25,30 +25,41 @@ const (
 )
 
 var precedences = map[token.TokenType]int{
-	token.ASSIGN:   ASSIGN,
-	token.QUESTION: TERNARY,
-	token.LOR:      LOGICAL_OR,
-	token.LAND:     LOGICAL_AND,
-	token.OR:       BIT_OR,
-	token.XOR:      BIT_XOR,
-	token.AND:      BIT_AND,
-	token.EQ:       EQUALS,
-	token.NEQ:      EQUALS,
-	token.LT:       LESSGREATER,
-	token.GT:       LESSGREATER,
-	token.LTE:      LESSGREATER,
-	token.GTE:      LESSGREATER,
-	token.SHL:      SHIFT,
-	token.SHR:      SHIFT,
-	token.PLUS:     SUM,
-	token.MINUS:    SUM,
-	token.MULTIPLY: PRODUCT,
-	token.DIVIDE:   PRODUCT,
-	token.MODULO:   PRODUCT,
-	token.LPAREN:   CALL,
-	token.LBRACK:   INDEX,
-	token.INC:      POSTFIX,
-	token.DEC:      POSTFIX,
+	token.ASSIGN:     ASSIGN,
+	token.ADD_ASSIGN: ASSIGN,
+	token.SUB_ASSIGN: ASSIGN,
+	token.MUL_ASSIGN: ASSIGN,
+	token.QUO_ASSIGN: ASSIGN,
+	token.REM_ASSIGN: ASSIGN,
+	token.AND_ASSIGN: ASSIGN,
+	token.OR_ASSIGN:  ASSIGN,
+	token.XOR_ASSIGN: ASSIGN,
+	token.SHL_ASSIGN: ASSIGN,
+	token.SHR_ASSIGN: ASSIGN,
+	token.QUESTION:   TERNARY,
+	token.LOR:        LOGICAL_OR,
+	token.LAND:       LOGICAL_AND,
+	token.OR:         BIT_OR,
+	token.XOR:        BIT_XOR,
+	token.AND:        BIT_AND,
+	token.EQ:         EQUALS,
+	token.NEQ:        EQUALS,
+	token.LT:         LESSGREATER,
+	token.GT:         LESSGREATER,
+	token.LTE:        LESSGREATER,
+	token.GTE:        LESSGREATER,
+	token.SHL:        SHIFT,
+	token.SHR:        SHIFT,
+	token.SHRU:       SHIFT,
+	token.PLUS:       SUM,
+	token.MINUS:      SUM,
+	token.MULTIPLY:   PRODUCT,
+	token.DIVIDE:     PRODUCT,
+	token.MODULO:     PRODUCT,
+	token.LPAREN:     CALL,
+	token.LBRACK:     INDEX,
+	token.INC:        POSTFIX,
+	token.DEC:        POSTFIX,
 }
 
 func GetPrecedence(tokenType token.TokenType) int {
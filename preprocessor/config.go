@@ -0,0 +1,179 @@
+package preprocessor
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Tramposo1312/pawn-parser/constexpr"
+	"github.com/Tramposo1312/pawn-parser/lexer"
+	"github.com/Tramposo1312/pawn-parser/token"
+)
+
+// Config is the equivalent of the pawncc command line: what a
+// Preprocessor knows before it reads the first line of the source.
+type Config struct {
+	// Resolver finds the files named by #include and #tryinclude. Without
+	// one, include lines are only passed on.
+	Resolver *Resolver
+
+	// Symbols are constants defined before the source is read, as pawncc
+	// defines a "NAME=value" argument. They satisfy "defined NAME" and
+	// can be used in #if conditions.
+	Symbols map[string]int64
+
+	// Macros are defined before the source is read, as if by a #define
+	// line. Keys are patterns and values the substitution text.
+	Macros map[string]string
+
+	// Version is the compiler version given by __Pawn, such as 0x0302 for
+	// SA-MP's pawncc. The zero value means 0x030A, the version of the
+	// open.mp compiler.
+	Version int64
+
+	// Time is the compilation time given by __date and __time. The zero
+	// value means the time the Preprocessor is created.
+	Time time.Time
+}
+
+// predefined are the constants pawncc defines for every compilation, for
+// its default 32-bit cells. __Pawn is replaced by Config.Version.
+var predefined = map[string]int64{
+	"__Pawn":   0x030A,
+	"cellbits": 32,
+	"cellmax":  2147483647,
+	"cellmin":  -2147483648,
+	"charbits": 8,
+	"charmax":  255,
+	"ucharmax": 16777215,
+}
+
+// commandLine is the file name given to diagnostics about Config.Macros.
+const commandLine = "<command line>"
+
+// Define adds a definition written the way it is given to pawncc or a C
+// compiler: "NAME=value", "NAME=", which is 0, or "NAME", which is 1,
+// optionally after "-D". A value that is not a constant number, such as
+// a string, is defined as a macro instead.
+func (c *Config) Define(definition string) error {
+	definition = strings.TrimPrefix(definition, "-D")
+	name, value, hasValue := strings.Cut(definition, "=")
+	if name == "" || name[:prefixLength(name)] != name || !isAlpha(name[0]) {
+		return fmt.Errorf("invalid definition %q: expected NAME or NAME=value", definition)
+	}
+
+	switch {
+	case !hasValue:
+		value = "1"
+	case strings.TrimSpace(value) == "":
+		value = "0"
+	}
+
+	if n, err := constexpr.Eval(lex(commandLine, value), nil); err == nil {
+		if c.Symbols == nil {
+			c.Symbols = make(map[string]int64)
+		}
+		c.Symbols[name] = n
+		return nil
+	}
+	if c.Macros == nil {
+		c.Macros = make(map[string]string)
+	}
+	c.Macros[name] = value
+	return nil
+}
+
+// NewWithConfig returns a Preprocessor for l that starts with c's
+// definitions in addition to the ones pawncc always makes.
+func NewWithConfig(l *lexer.Lexer, c Config) *Preprocessor {
+	pp := New(l)
+	pp.SetResolver(c.Resolver)
+	if c.Version != 0 {
+		pp.symbols["__Pawn"] = c.Version
+	}
+	for name, value := range c.Symbols {
+		pp.symbols[name] = value
+	}
+	if !c.Time.IsZero() {
+		pp.time = c.Time
+	}
+
+	patterns := make([]string, 0, len(c.Macros))
+	for pattern := range c.Macros {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+	directive := token.Token{Type: token.DEFINE, Literal: "#define", File: commandLine, Line: 1, Column: 1}
+	for _, pattern := range patterns {
+		pp.define(directive, lex(commandLine, pattern+" "+c.Macros[pattern]))
+	}
+	return pp
+}
+
+// substituteBuiltins replaces the names whose value depends on where
+// they are used: __line and __file become the position of the name, and
+// __date and __time the compilation time.
+func (pp *Preprocessor) substituteBuiltins(t *text) {
+	for start := 0; start < len(t.buf); start++ {
+		switch ch := t.buf[start]; {
+		case ch == '"' || ch == '\'':
+			start = skipString(t.buf, start)
+			continue
+		case !isAlpha(ch):
+			for isDigit(ch) && start+1 < len(t.buf) && isAlphaNum(t.buf[start+1]) {
+				start++
+			}
+			continue
+		}
+
+		end := start + prefixLength(string(t.buf[start:]))
+		at := t.pos[start]
+		var value string
+		switch string(t.buf[start:end]) {
+		case "__line":
+			value = strconv.Itoa(at.Line)
+		case "__file":
+			value = quote(at.File)
+		case "__date":
+			value = quote(pp.time.Format("02 Jan 2006"))
+		case "__time":
+			value = quote(pp.time.Format("15:04:05"))
+		default:
+			start = end - 1
+			continue
+		}
+
+		replacement := &text{buf: []byte(value)}
+		for range value {
+			replacement.pos = append(replacement.pos, at)
+		}
+		t.replace(start, end, replacement)
+		start += len(value) - 1
+	}
+}
+
+// quote returns s as a Pawn string literal.
+func quote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
+
+func isBuiltin(name string) bool {
+	switch name {
+	case "__line", "__file", "__date", "__time":
+		return true
+	}
+	return false
+}
+
+// lex returns the tokens of a definition given outside the source.
+func lex(filename, input string) []token.Token {
+	l := lexer.NewFile(filename, input)
+	tokens := []token.Token{}
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		tokens = append(tokens, tok)
+	}
+	return tokens
+}
@@ -0,0 +1,127 @@
+package preprocessor
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Tramposo1312/pawn-parser/lexer"
+)
+
+func TestConfigDefine(t *testing.T) {
+	var c Config
+	for _, definition := range []string{"DEBUG", "-DSAMP_COMPAT=", "MAX_PLAYERS=0x100", "VERSION=\"1.0\""} {
+		if err := c.Define(definition); err != nil {
+			t.Fatalf("Define(%q) failed: %s", definition, err)
+		}
+	}
+
+	expected := map[string]int64{"DEBUG": 1, "SAMP_COMPAT": 0, "MAX_PLAYERS": 256}
+	for name, value := range expected {
+		if got, ok := c.Symbols[name]; !ok || got != value {
+			t.Errorf("Symbols[%q] = %d, %t. expected %d", name, got, ok, value)
+		}
+	}
+	if c.Macros["VERSION"] != `"1.0"` {
+		t.Errorf("VERSION not defined as a macro. got=%v", c.Macros)
+	}
+
+	for _, definition := range []string{"", "=1", "1X=2", "A B=1"} {
+		if err := c.Define(definition); err == nil {
+			t.Errorf("expected an error for %q", definition)
+		}
+	}
+}
+
+func TestConfiguredBuilds(t *testing.T) {
+	input := `#if defined OPENMP
+new build = 2;
+#elseif DEBUG > 1
+new build = 1;
+#else
+new build = 0;
+#endif
+new version[] = VERSION;
+`
+
+	tests := []struct {
+		definitions []string
+		expected    string
+	}{
+		{[]string{"DEBUG=0", "VERSION=\"a\""}, "new build = 0 ; new version [ ] = a ;"},
+		{[]string{"DEBUG=2", "VERSION=\"a\""}, "new build = 1 ; new version [ ] = a ;"},
+		{[]string{"OPENMP", "DEBUG=2", "VERSION=\"b\""}, "new build = 2 ; new version [ ] = b ;"},
+	}
+
+	for _, tt := range tests {
+		var c Config
+		for _, definition := range tt.definitions {
+			if err := c.Define(definition); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		pp := NewWithConfig(lexer.New(input), c)
+		literals := []string{}
+		for _, tok := range readAll(pp) {
+			literals = append(literals, tok.Literal)
+		}
+		if len(pp.Errors()) > 0 {
+			t.Fatalf("%v: preprocessor errors: %v", tt.definitions, pp.Errors())
+		}
+		if got := strings.Join(literals, " "); got != tt.expected {
+			t.Errorf("%v: expected=%q, got=%q", tt.definitions, tt.expected, got)
+		}
+	}
+}
+
+func TestPredefinedSymbols(t *testing.T) {
+	input := `#if __Pawn >= 0x0300 && cellbits == 32 && cellmax == 0x7FFFFFFF && defined __file
+new line = __line, file[] = __file;
+new date[] = __date, time[] = __time;
+#endif
+`
+	c := Config{Time: time.Date(2024, time.March, 5, 14, 7, 9, 0, time.UTC)}
+	pp := NewWithConfig(lexer.NewFile("main.pwn", input), c)
+
+	literals := []string{}
+	for _, tok := range readAll(pp) {
+		literals = append(literals, tok.Literal)
+	}
+	if len(pp.Errors()) > 0 {
+		t.Fatalf("preprocessor errors: %v", pp.Errors())
+	}
+
+	expected := "new line = 2 , file [ ] = main.pwn ; new date [ ] = 05 Mar 2024 , time [ ] = 14:07:09 ;"
+	if got := strings.Join(literals, " "); got != expected {
+		t.Errorf("expected=%q\ngot=     %q", expected, got)
+	}
+}
+
+func TestConfigVersion(t *testing.T) {
+	input := `#if __Pawn >= 0x030A
+new compiler = 310;
+#else
+new compiler = 302;
+#endif
+`
+	tests := []struct {
+		version  int64
+		expected string
+	}{
+		{0, "new compiler = 310 ;"},
+		{0x030A, "new compiler = 310 ;"},
+		{0x0302, "new compiler = 302 ;"},
+	}
+
+	for _, tt := range tests {
+		pp := NewWithConfig(lexer.New(input), Config{Version: tt.version})
+		literals := []string{}
+		for _, tok := range readAll(pp) {
+			literals = append(literals, tok.Literal)
+		}
+		if got := strings.Join(literals, " "); got != tt.expected {
+			t.Errorf("Version %#x: expected=%q, got=%q", tt.version, tt.expected, got)
+		}
+	}
+}
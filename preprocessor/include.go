@@ -0,0 +1,213 @@
+package preprocessor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Tramposo1312/pawn-parser/lexer"
+	"github.com/Tramposo1312/pawn-parser/token"
+)
+
+// maxIncludeDepth bounds how deeply includes may nest, so that a file that
+// keeps including itself, by undefining its _inc_ symbol first, is
+// reported instead of exhausting memory.
+const maxIncludeDepth = 50
+
+// includeExtensions are appended, in order, to an included name that does
+// not exist as written.
+var includeExtensions = []string{".inc", ".pwn", ".p"}
+
+// source is an open file on the include stack.
+type source struct {
+	l *lexer.Lexer
+
+	// conditionals is how many #if blocks were open when the file was
+	// included. Blocks the file opens are closed when it ends.
+	conditionals int
+
+	// lineOffset and name are set by #line and #file, and change the
+	// positions given to the file's tokens.
+	lineOffset int
+	name       string
+}
+
+// relocate applies #line and #file to a token read from the file.
+func (s *source) relocate(tok *token.Token) {
+	tok.Line += s.lineOffset
+	if s.name != "" {
+		tok.File = s.name
+	}
+}
+
+// Resolver finds the files named by #include and #tryinclude.
+type Resolver struct {
+	// SearchPaths are the include directories, as given to pawncc with
+	// -i, in the order they are searched.
+	SearchPaths []string
+}
+
+func NewResolver(searchPaths ...string) *Resolver {
+	return &Resolver{SearchPaths: searchPaths}
+}
+
+// Resolve returns the path of the file an include names. from is the file
+// containing the directive: a "name" include is looked for in its
+// directory before the search paths, a <name> include only in the search
+// paths.
+func (r *Resolver) Resolve(name string, system bool, from string) (string, bool) {
+	if filepath.IsAbs(name) {
+		return findFile(name)
+	}
+
+	dirs := r.SearchPaths
+	if !system {
+		dirs = append([]string{filepath.Dir(from)}, dirs...)
+	}
+	for _, dir := range dirs {
+		if path, ok := findFile(filepath.Join(dir, name)); ok {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+// findFile returns path, or path with one of the include extensions, if
+// that is an existing file.
+func findFile(path string) (string, bool) {
+	candidates := []string{path}
+	for _, ext := range includeExtensions {
+		candidates = append(candidates, path+ext)
+	}
+	for _, candidate := range candidates {
+		if info, err := os.Stat(candidate); err == nil && info.Mode().IsRegular() {
+			return candidate, true
+		}
+	}
+	return "", false
+}
+
+// Open reads the named file and returns a Preprocessor for it configured
+// by c. With c.Resolver set, the tokens it returns are the whole
+// translation unit.
+func Open(filename string, c Config) (*Preprocessor, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return NewWithConfig(lexer.NewFile(filename, string(content)), c), nil
+}
+
+// SetResolver makes the preprocessor read the files named by #include and
+// #tryinclude. Without a resolver include lines are only passed on.
+func (pp *Preprocessor) SetResolver(r *Resolver) {
+	pp.resolver = r
+}
+
+// include handles an #include or #tryinclude line. The directive is always
+// passed on so the parser can record it; when a resolver is set the named
+// file is then read in its place, and preprocessed output shows only the
+// file.
+func (pp *Preprocessor) include(tok token.Token, args []token.Token) {
+	if pp.resolver == nil {
+		pp.passThrough(tok, args)
+		return
+	}
+	pp.pending = append(pp.pending, tok)
+	pp.pending = append(pp.pending, args...)
+
+	name, system, ok := splitIncludeName(newText(args).String())
+	if !ok {
+		pp.errorf(tok, "invalid file name after %s", tok.Literal)
+		return
+	}
+
+	path, found := pp.resolver.Resolve(name, system, pp.lexer().File())
+	if !found {
+		if tok.Type != token.TRYINCLUDE {
+			pp.errorf(tok, "cannot read from file: %q", name)
+		}
+		return
+	}
+
+	// Like pawncc, a file is only read if its _inc_ symbol is not yet
+	// defined; "#undef _inc_name" allows it to be included again.
+	symbol := includeSymbol(path)
+	if _, ok := pp.symbols[symbol]; ok {
+		return
+	}
+	if len(pp.files) >= maxIncludeDepth {
+		pp.errorf(tok, "includes nested too deeply at %q", name)
+		return
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		pp.errorf(tok, "cannot read from file: %v", err)
+		return
+	}
+	l := lexer.NewFile(path, string(content))
+	l.ReportNewlines(true)
+	pp.files = append(pp.files, &source{l: l, conditionals: len(pp.conditionals)})
+	pp.symbols[symbol] = 1
+}
+
+// includeSymbol returns the constant pawncc defines for an included file:
+// "_inc_" followed by the file name without its directory or extension.
+func includeSymbol(path string) string {
+	name := filepath.Base(path)
+	if i := strings.IndexByte(name, '.'); i >= 0 {
+		name = name[:i]
+	}
+	return "_inc_" + name
+}
+
+// endInput handles #endinput, which stops reading the current file. Any
+// #if blocks the file opened are closed with it, since the common include
+// guard ends the file from inside one.
+func (pp *Preprocessor) endInput(tok token.Token) {
+	if len(pp.files) > 1 {
+		pp.closeFile(false)
+		return
+	}
+	pp.conditionals = nil
+	pp.eof = token.Token{Type: token.EOF, File: tok.File, Line: tok.Line, Column: tok.Column}
+	pp.done = true
+}
+
+// closeFile returns from an included file to the one that included it.
+// At the end of the file, blocks left open are reported.
+func (pp *Preprocessor) closeFile(atEOF bool) {
+	file := pp.files[len(pp.files)-1]
+	if atEOF {
+		for _, cond := range pp.conditionals[file.conditionals:] {
+			pp.errorf(cond.Token, "%s without matching #endif", cond.Token.Literal)
+		}
+	}
+	pp.conditionals = pp.conditionals[:file.conditionals]
+	pp.files = pp.files[:len(pp.files)-1]
+}
+
+// splitIncludeName takes the name from the text after an include
+// directive: <name>, "name", or a bare name, which pawncc searches for
+// the way it does a <name>.
+func splitIncludeName(text string) (name string, system bool, ok bool) {
+	switch {
+	case text == "":
+		return "", false, false
+	case text[0] == '<':
+		end := strings.IndexByte(text, '>')
+		if end < 0 {
+			return "", false, false
+		}
+		return strings.TrimSpace(text[1:end]), true, true
+	case text[0] == '"':
+		end := strings.IndexByte(text[1:], '"')
+		if end < 0 {
+			return "", false, false
+		}
+		return text[1 : end+1], false, true
+	default:
+		return strings.Fields(text)[0], true, true
+	}
+}
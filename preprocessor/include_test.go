@@ -0,0 +1,237 @@
+package preprocessor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Tramposo1312/pawn-parser/ast"
+	"github.com/Tramposo1312/pawn-parser/parser"
+	"github.com/Tramposo1312/pawn-parser/token"
+)
+
+// writeFiles creates files, keyed by slash separated paths relative to a
+// temporary directory, and returns that directory.
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+// readAll returns every token pp produces.
+func readAll(pp *Preprocessor) []token.Token {
+	tokens := []token.Token{}
+	for tok := pp.NextToken(); tok.Type != token.EOF; tok = pp.NextToken() {
+		tokens = append(tokens, tok)
+	}
+	return tokens
+}
+
+func TestResolveIncludes(t *testing.T) {
+	root := writeFiles(t, map[string]string{
+		"include/a_samp.inc":        "native print(const string[]);\n",
+		"include/YSI/y_hooks.inc":   "#define hook%0(%1) public%0(%1)\n",
+		"gamemodes/main.pwn":        "#include <a_samp>\n#include \"utils\"\n#include <YSI/y_hooks>\nnew x = UTIL;\n",
+		"gamemodes/utils.pwn":       "#define UTIL 1\n",
+		"gamemodes/lib/helpers.p":   "\n",
+		"include/utils.inc":         "#define UTIL 2\n",
+		"include/streamer.inc.skip": "\n",
+	})
+	r := NewResolver(filepath.Join(root, "include"))
+	from := filepath.Join(root, "gamemodes", "main.pwn")
+
+	tests := []struct {
+		name   string
+		system bool
+		want   string
+	}{
+		{"a_samp", true, "include/a_samp.inc"},
+		{"a_samp.inc", true, "include/a_samp.inc"},
+		{"YSI/y_hooks", true, "include/YSI/y_hooks.inc"},
+		{"utils", false, "gamemodes/utils.pwn"},
+		{"utils", true, "include/utils.inc"},
+		{"lib/helpers", false, "gamemodes/lib/helpers.p"},
+		{"streamer", true, ""},
+	}
+
+	for _, tt := range tests {
+		path, ok := r.Resolve(tt.name, tt.system, from)
+		want := ""
+		if tt.want != "" {
+			want = filepath.Join(root, filepath.FromSlash(tt.want))
+		}
+		if ok != (want != "") || path != want {
+			t.Errorf("Resolve(%q, %t) = %q, %t. expected %q", tt.name, tt.system, path, ok, want)
+		}
+	}
+}
+
+func TestIncludeExpansion(t *testing.T) {
+	root := writeFiles(t, map[string]string{
+		"include/a_samp.inc": "#define MAX_PLAYERS 500\nnative print(const string[]);\n",
+		"main.pwn":           "#include <a_samp>\n#tryinclude <missing>\nnew players[MAX_PLAYERS];\n",
+	})
+
+	pp, err := Open(filepath.Join(root, "main.pwn"), Config{Resolver: NewResolver(filepath.Join(root, "include"))})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	literals := []string{}
+	files := map[string]bool{}
+	for _, tok := range readAll(pp) {
+		literals = append(literals, tok.Literal)
+		files[filepath.Base(tok.File)] = true
+	}
+	if len(pp.Errors()) > 0 {
+		t.Fatalf("preprocessor errors: %v", pp.Errors())
+	}
+
+	expected := "#include < a_samp > native print ( const string [ ] ) ; #tryinclude < missing > new players [ 500 ] ;"
+	if got := strings.Join(literals, " "); got != expected {
+		t.Errorf("expected=%q\ngot=     %q", expected, got)
+	}
+	if !files["a_samp.inc"] || !files["main.pwn"] {
+		t.Errorf("tokens not attributed to both files. got=%v", files)
+	}
+}
+
+func TestMissingInclude(t *testing.T) {
+	root := writeFiles(t, map[string]string{
+		"main.pwn": "#include <missing>\n#include \"self\"\n",
+		"self.inc": "#undef _inc_self\n#include \"self\"\n",
+	})
+
+	pp, err := Open(filepath.Join(root, "main.pwn"), Config{Resolver: NewResolver()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	readAll(pp)
+
+	if len(pp.Errors()) != 2 {
+		t.Fatalf("expected 2 errors. got=%v", pp.Errors())
+	}
+	if !strings.Contains(pp.Errors()[0], "main.pwn:1") || !strings.Contains(pp.Errors()[0], "missing") {
+		t.Errorf("missing include not reported. got=%q", pp.Errors()[0])
+	}
+	if !strings.Contains(pp.Errors()[1], "nested too deeply") {
+		t.Errorf("recursive include not reported. got=%q", pp.Errors()[1])
+	}
+}
+
+func TestParseTranslationUnit(t *testing.T) {
+	root := writeFiles(t, map[string]string{
+		"include/a_samp.inc": "native SendClientMessage(playerid, color, message);\n",
+		"include/colors.inc": "#define COLOR_RED 0xFF0000FF\n",
+		"main.pwn": `#include <a_samp>
+#include <colors>
+
+public OnPlayerConnect(playerid)
+{
+	SendClientMessage(playerid, COLOR_RED, "hi");
+	return 1;
+}
+`,
+	})
+
+	pp, err := Open(filepath.Join(root, "main.pwn"), Config{Resolver: NewResolver(filepath.Join(root, "include"))})
+	if err != nil {
+		t.Fatal(err)
+	}
+	program, err := parser.New(pp).ParseProgram()
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	if len(pp.Errors()) > 0 {
+		t.Fatalf("preprocessor errors: %v", pp.Errors())
+	}
+
+	if len(program.Statements) != 4 {
+		t.Fatalf("program.Statements does not contain 4 statements. got=%d",
+			len(program.Statements))
+	}
+
+	native, ok := program.Statements[1].(*ast.NativeFunctionDeclaration)
+	if !ok {
+		t.Fatalf("program.Statements[1] is not ast.NativeFunctionDeclaration. got=%T",
+			program.Statements[1])
+	}
+	if filepath.Base(native.Token.File) != "a_samp.inc" {
+		t.Errorf("native declared in wrong file. got=%q", native.Token.File)
+	}
+
+	decl, ok := program.Statements[3].(*ast.FunctionDeclaration)
+	if !ok {
+		t.Fatalf("program.Statements[3] is not ast.FunctionDeclaration. got=%T",
+			program.Statements[3])
+	}
+	if filepath.Base(decl.Token.File) != "main.pwn" || decl.Token.Line != 4 {
+		t.Errorf("function at wrong position. got=%s:%d", decl.Token.File, decl.Token.Line)
+	}
+}
+
+func TestIncludeGuards(t *testing.T) {
+	root := writeFiles(t, map[string]string{
+		"include/a_samp.inc": `#if defined _samp_included
+	#endinput
+#endif
+#define _samp_included
+native print(const string[]);
+`,
+		"include/core.inc": "#include <a_samp>\nnew core;\n",
+		"include/old.inc": `new old;
+#endinput
+new never;
+`,
+		"main.pwn": `#include <a_samp>
+#include <core>
+#include <a_samp.inc>
+#include <old>
+#if defined _inc_a_samp && defined _inc_core && _inc_old
+new all;
+#endif
+#undef _inc_core
+#include <core>
+#endinput
+new after;
+`,
+	})
+
+	pp, err := Open(filepath.Join(root, "main.pwn"), Config{Resolver: NewResolver(filepath.Join(root, "include"))})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Show each include line as "|".
+	literals := []string{}
+	var include token.Token
+	for _, tok := range readAll(pp) {
+		switch {
+		case tok.Type == token.INCLUDE:
+			include = tok
+			literals = append(literals, "|")
+		case tok.File != include.File || tok.Line != include.Line:
+			literals = append(literals, tok.Literal)
+		}
+	}
+	if len(pp.Errors()) > 0 {
+		t.Fatalf("preprocessor errors: %v", pp.Errors())
+	}
+
+	// a_samp.inc is read once, core.inc twice because its symbol was
+	// undefined, and nothing after an #endinput is seen.
+	expected := "| native print ( const string [ ] ) ; | | new core ; | | new old ; new all ; | | new core ;"
+	if got := strings.Join(literals, " "); got != expected {
+		t.Errorf("expected=%q\ngot=     %q", expected, got)
+	}
+}
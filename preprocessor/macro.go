@@ -0,0 +1,395 @@
+package preprocessor
+
+import (
+	"strings"
+
+	"github.com/Tramposo1312/pawn-parser/lexer"
+	"github.com/Tramposo1312/pawn-parser/token"
+)
+
+// maxSubstitutions bounds the number of macro substitutions made on a
+// single line. Pawn rescans a line after every substitution, so a macro
+// whose replacement contains its own pattern would otherwise never stop.
+const maxSubstitutions = 10000
+
+// maxLineLength is the longest a line may grow to through substitution,
+// matching the line buffer of the community compiler.
+const maxLineLength = 16384
+
+// Macro is a #define. Pawn macros are text patterns rather than C style
+// names with parameter lists: Pattern is everything up to the first
+// whitespace after "#define" ("SendError(%0,%1)", "CMD:%0(%1)"), and Name
+// is its leading alphanumeric prefix ("SendError", "CMD"), which is how a
+// use of the macro is found. The rest of the pattern is matched against
+// the source text, capturing the arguments %0 to %9.
+type Macro struct {
+	Token        token.Token // the '#define' token
+	Name         string
+	Pattern      string
+	Substitution string
+	Body         []token.Token // the substitution as written
+}
+
+func (pp *Preprocessor) define(tok token.Token, args []token.Token) {
+	pattern, body := splitDefinition(args)
+	if pattern == "" {
+		pp.errorf(tok, "expected a macro name after #define")
+		return
+	}
+
+	name := pattern[:prefixLength(pattern)]
+	if name == "" {
+		pp.errorf(tok, "invalid macro pattern %q: it must start with a name", pattern)
+		return
+	}
+
+	macro := &Macro{
+		Token:        tok,
+		Name:         name,
+		Pattern:      pattern,
+		Substitution: newText(body).String(),
+		Body:         body,
+	}
+
+	if old, ok := pp.macros[name]; ok && (old.Pattern != macro.Pattern || old.Substitution != macro.Substitution) {
+		pp.warnf(tok, "redefinition of macro %s", pattern)
+	}
+	pp.macros[name] = macro
+}
+
+// splitDefinition splits the tokens after "#define" into the pattern text,
+// which ends at the first whitespace, and the substitution tokens.
+func splitDefinition(args []token.Token) (string, []token.Token) {
+	if len(args) == 0 {
+		return "", nil
+	}
+
+	n := 1
+	for n < len(args) && args[n].Line == args[n-1].Line && args[n].Column == args[n-1].End() {
+		n++
+	}
+	return newText(args[:n]).String(), args[n:]
+}
+
+// expandLine substitutes every macro in a line of source tokens, then
+// the builtin names such as __line.
+func (pp *Preprocessor) expandLine(line []token.Token) []token.Token {
+	return pp.expandText(line).tokens()
+}
+
+// expandText is expandLine before the result is split into tokens.
+func (pp *Preprocessor) expandText(line []token.Token) *text {
+	t := newText(line)
+	pp.substituteAll(t)
+	pp.substituteBuiltins(t)
+	return t
+}
+
+// substituteAll is Pawn's substallpatterns: it scans the line for names,
+// and whenever a name is the prefix of a macro whose full pattern matches,
+// replaces the match and rescans from the same place, so the replacement
+// may itself be substituted.
+func (pp *Preprocessor) substituteAll(t *text) {
+	count := 0
+	start := 0
+	for start < len(t.buf) {
+		// Find the start of the next name, skipping strings, character
+		// literals and numbers.
+		for start < len(t.buf) && !isAlpha(t.buf[start]) {
+			switch {
+			case t.buf[start] == '"' || t.buf[start] == '\'':
+				start = skipString(t.buf, start)
+			case isDigit(t.buf[start]):
+				for start+1 < len(t.buf) && isAlphaNum(t.buf[start+1]) {
+					start++
+				}
+			}
+			start++
+		}
+		if start >= len(t.buf) {
+			return
+		}
+
+		end := start
+		for end < len(t.buf) && isAlphaNum(t.buf[end]) {
+			end++
+		}
+
+		macro, ok := pp.macros[string(t.buf[start:end])]
+		if !ok || !pp.substitute(t, start, macro) {
+			start = end
+			continue
+		}
+
+		count++
+		if count > maxSubstitutions {
+			pp.errorf(macro.Token, "macro recursion limit exceeded while expanding %s", macro.Pattern)
+			return
+		}
+		if len(t.buf) > maxLineLength {
+			pp.errorf(macro.Token, "input line too long after expanding %s", macro.Pattern)
+			return
+		}
+	}
+}
+
+// argSpan is the text captured for a %0-%9 argument: t.buf[from:to].
+type argSpan struct {
+	from, to int
+	set      bool
+}
+
+// substitute is Pawn's substpattern: it matches macro's pattern against
+// t.buf at start and, on success, replaces the matched text.
+func (pp *Preprocessor) substitute(t *text, start int, macro *Macro) bool {
+	pattern := macro.Pattern
+	buf := t.buf
+	var args [10]argSpan
+
+	p := len(macro.Name)
+	s := start + p
+	match := true
+	for match && s < len(buf) && p < len(pattern) {
+		switch {
+		case pattern[p] == '%':
+			p++
+			if p >= len(pattern) || !isDigit(pattern[p]) {
+				match = false
+				break
+			}
+			arg := pattern[p] - '0'
+			p++
+
+			// Capture up to the pattern character after the argument,
+			// skipping strings and bracketed groups.
+			var next byte
+			if p < len(pattern) {
+				next = pattern[p]
+			}
+			e := s
+			for e < len(buf) && buf[e] != next && buf[e] != '\n' {
+				if buf[e] == '"' || buf[e] == '\'' {
+					e = skipString(buf, e)
+				} else if strings.IndexByte("({[", buf[e]) >= 0 {
+					e = skipGroup(buf, e)
+				}
+				if e < len(buf) {
+					e++
+				}
+			}
+			args[arg] = argSpan{from: s, to: e, set: true}
+
+			switch {
+			case e < len(buf) && buf[e] == next && next != 0:
+				s = e + 1
+			case e < len(buf) && buf[e] == '\n' && next == ';' && p+1 == len(pattern) && !pp.needSemicolon:
+				// A trailing ';' in the pattern may match the end of line.
+				s = e
+			default:
+				match = false
+				s = e
+			}
+			p++
+		case pattern[p] == ';' && p+1 == len(pattern) && !pp.needSemicolon:
+			// The source may have ';' or end the line here.
+			for s < len(buf) && buf[s] <= ' ' {
+				s++
+			}
+			switch {
+			case s < len(buf) && buf[s] == ';':
+				s++
+			case s < len(buf) && buf[s] != '\n':
+				match = false
+			}
+			p++
+		default:
+			// Whitespace may separate two non-alphanumeric characters,
+			// unless they are the same symbol.
+			if !isAlphaNum(pattern[p]) && pattern[p-1] != pattern[p] {
+				for s < len(buf) && buf[s] <= ' ' {
+					s++
+				}
+			}
+			if s >= len(buf) || buf[s] != pattern[p] {
+				match = false
+			} else {
+				s++
+			}
+			p++
+		}
+	}
+
+	if p < len(pattern) {
+		match = false
+	}
+	// A pattern ending in a name may not match just the start of a longer
+	// name in the source.
+	if match && isAlphaNum(pattern[len(pattern)-1]) && s < len(buf) && isAlphaNum(buf[s]) {
+		match = false
+	}
+	if !match {
+		return false
+	}
+
+	if s >= len(buf) {
+		s = len(buf) - 1 // keep the end of line marker
+	}
+
+	// Text from the substitution is placed at the invocation, with an
+	// origin recording the expansion. Arguments keep their own positions.
+	at := t.pos[start]
+	at.origin = &token.Origin{
+		Macro:      macro.Pattern,
+		Definition: macro.Token.Position(),
+		Invocation: at.Position,
+		Parent:     at.origin,
+	}
+	replacement := &text{}
+	sub := macro.Substitution
+	for i := 0; i < len(sub); i++ {
+		if sub[i] == '%' && i+1 < len(sub) && isDigit(sub[i+1]) && args[sub[i+1]-'0'].set {
+			span := args[sub[i+1]-'0']
+			replacement.buf = append(replacement.buf, buf[span.from:span.to]...)
+			replacement.pos = append(replacement.pos, t.pos[span.from:span.to]...)
+			i++
+			continue
+		}
+		replacement.buf = append(replacement.buf, sub[i])
+		replacement.pos = append(replacement.pos, at)
+	}
+
+	t.replace(start, s, replacement)
+	return true
+}
+
+// skipString returns the index of the quote closing the string or
+// character literal starting at buf[i].
+func skipString(buf []byte, i int) int {
+	quote := buf[i]
+	for i++; i < len(buf) && buf[i] != quote && buf[i] != '\n'; i++ {
+		if buf[i] == '\\' {
+			i++
+		}
+	}
+	return i
+}
+
+// skipGroup returns the index of the bracket closing the group opened at
+// buf[i], skipping nested groups and strings.
+func skipGroup(buf []byte, i int) int {
+	open := buf[i]
+	close := map[byte]byte{'(': ')', '[': ']', '{': '}'}[open]
+	nest := 0
+	for ; i < len(buf) && buf[i] != '\n'; i++ {
+		switch buf[i] {
+		case open:
+			nest++
+		case close:
+			nest--
+			if nest == 0 {
+				return i
+			}
+		case '"', '\'':
+			i = skipString(buf, i)
+		}
+	}
+	return i
+}
+
+// position is where a byte of expanded text came from in the source, and
+// the macro expansion that produced it, if any.
+type position struct {
+	token.Position
+	origin *token.Origin
+}
+
+// text is a logical source line as bytes, with the source position of
+// every byte so the tokens lexed from it after substitution can still be
+// placed in the file. It always ends with a '\n' marker, mirroring the
+// line buffer pawncc matches patterns against.
+type text struct {
+	buf []byte
+	pos []position
+}
+
+// newText rebuilds the source text of a line from its tokens, with a
+// single space wherever the source had whitespace.
+func newText(tokens []token.Token) *text {
+	t := &text{}
+	for i, tok := range tokens {
+		if i > 0 && (tok.Line != tokens[i-1].Line || tok.Column > tokens[i-1].End()) {
+			t.buf = append(t.buf, ' ')
+			t.pos = append(t.pos, position{tok.Position(), tok.Origin})
+		}
+		lit := tok.Text()
+		for j := 0; j < len(lit); j++ {
+			t.buf = append(t.buf, lit[j])
+			at := position{tok.Position(), tok.Origin}
+			at.Column += j
+			t.pos = append(t.pos, at)
+		}
+	}
+	end := position{}
+	if len(tokens) > 0 {
+		last := tokens[len(tokens)-1]
+		end = position{last.Position(), last.Origin}
+		end.Column = last.End()
+	}
+	t.buf = append(t.buf, '\n')
+	t.pos = append(t.pos, end)
+	return t
+}
+
+// String returns the text without its end of line marker.
+func (t *text) String() string {
+	return strings.TrimSuffix(string(t.buf), "\n")
+}
+
+// replace swaps t.buf[from:to] for the contents of r.
+func (t *text) replace(from, to int, r *text) {
+	buf := append([]byte{}, t.buf[:from]...)
+	buf = append(buf, r.buf...)
+	t.buf = append(buf, t.buf[to:]...)
+
+	pos := append([]position{}, t.pos[:from]...)
+	pos = append(pos, r.pos...)
+	t.pos = append(pos, t.pos[to:]...)
+}
+
+// tokens lexes the text, giving each token the source position of its
+// first byte.
+func (t *text) tokens() []token.Token {
+	l := lexer.New(t.String())
+	out := []token.Token{}
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		if tok.Type == token.COMMENT {
+			continue
+		}
+		at := t.pos[tok.Column-1]
+		tok.File, tok.Line, tok.Column = at.File, at.Line, at.Column
+		tok.Origin = at.origin
+		out = append(out, tok)
+	}
+	return out
+}
+
+// prefixLength returns the length of the name a macro pattern starts with.
+func prefixLength(pattern string) int {
+	n := 0
+	for n < len(pattern) && isAlphaNum(pattern[n]) {
+		n++
+	}
+	return n
+}
+
+func isAlpha(ch byte) bool {
+	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch == '_' || ch == '@'
+}
+
+func isDigit(ch byte) bool {
+	return '0' <= ch && ch <= '9'
+}
+
+func isAlphaNum(ch byte) bool {
+	return isAlpha(ch) || isDigit(ch)
+}
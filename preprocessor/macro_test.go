@@ -0,0 +1,245 @@
+package preprocessor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Tramposo1312/pawn-parser/ast"
+	"github.com/Tramposo1312/pawn-parser/lexer"
+	"github.com/Tramposo1312/pawn-parser/parser"
+	"github.com/Tramposo1312/pawn-parser/token"
+)
+
+// The definitions below are taken from widely used includes (zcmd, the
+// SA-MP wiki key macros, YSI, Dini) so that the matching rules are checked
+// against real code rather than just textbook cases.
+func TestPatternMacros(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			"two arguments",
+			"#define SendError(%0,%1) SendClientMessage(%0, COLOR_RED, %1)\nSendError(playerid, \"No access\");",
+			"SendClientMessage ( playerid , COLOR_RED , No access ) ;",
+		},
+		{
+			"argument used several times",
+			"#define PRESSED(%0) (((newkeys & (%0)) == (%0)) && ((oldkeys & (%0)) != (%0)))\nif (PRESSED(KEY_FIRE)) { }",
+			"if ( ( ( ( newkeys & ( KEY_FIRE ) ) == ( KEY_FIRE ) ) && ( ( oldkeys & ( KEY_FIRE ) ) != ( KEY_FIRE ) ) ) ) { }",
+		},
+		{
+			"zcmd prefix macro with token pasting",
+			"#define CMD:%0(%1) forward cmd_%0(%1); public cmd_%0(%1)\nCMD:kick(playerid, params[]) { }",
+			"forward cmd_kick ( playerid , params [ ] ) ; public cmd_kick ( playerid , params [ ] ) { }",
+		},
+		{
+			"macro expanding to another macro",
+			"#define CMD:%0(%1) forward cmd_%0(%1); public cmd_%0(%1)\n#define COMMAND:%1(%2) CMD:%1(%2)\nCOMMAND:ban(playerid, params[]) { }",
+			"forward cmd_ban ( playerid , params [ ] ) ; public cmd_ban ( playerid , params [ ] ) { }",
+		},
+		{
+			"bracketed argument containing the terminator",
+			"#define isnull(%1) ((!(%1[0])) || (((%1[0]) == '\\1') && (!(%1[1]))))\nif (isnull(Get(a, b))) { }",
+			`if ( ( ( ! ( Get ( a , b ) [ 0 ] ) ) || ( ( ( Get ( a , b ) [ 0 ] ) == '\1' ) && ( ! ( Get ( a , b ) [ 1 ] ) ) ) ) ) { }`,
+		},
+		{
+			"string argument containing the terminator",
+			"#define Log(%0) printf(\"[log] %s\", %0)\nLog(\"a) b\");",
+			"printf ( [log] %s , a) b ) ;",
+		},
+		{
+			"whitespace between symbols in the source",
+			"#define SendError(%0,%1) SendClientMessage(%0, -1, %1)\nSendError ( playerid , msg );",
+			"SendClientMessage ( playerid , - 1 , msg ) ;",
+		},
+		{
+			"prefix must be a whole name",
+			"#define MAX 10\nnew MAXIMUM = MAX;",
+			"new MAXIMUM = 10 ;",
+		},
+		{
+			"pattern that does not match is left alone",
+			"#define GetX(%0) gX[%0]\nnew GetX = 1;",
+			"new GetX = 1 ;",
+		},
+		{
+			"trailing semicolon matches end of line",
+			"#define Kill(%0); SetPlayerHealth(%0, 0.0);\nKill(playerid)\nKill(1);",
+			"SetPlayerHealth ( playerid , 0.0 ) ; SetPlayerHealth ( 1 , 0.0 ) ;",
+		},
+		{
+			"multi-line definition",
+			"#define DIALOG:%0(%1) \\\n\tforward dialog_%0(%1); \\\n\tpublic dialog_%0(%1)\nDIALOG:login(playerid, response) { }",
+			"forward dialog_login ( playerid , response ) ; public dialog_login ( playerid , response ) { }",
+		},
+		{
+			"strings are not searched",
+			"#define MAX_PLAYERS 500\nprint(\"MAX_PLAYERS\");",
+			"print ( MAX_PLAYERS ) ;",
+		},
+		{
+			"undef removes the pattern",
+			"#define Foo(%0) Bar(%0)\n#undef Foo\nFoo(1);",
+			"Foo ( 1 ) ;",
+		},
+		{
+			"redefinition replaces the pattern",
+			"#define MAX_PLAYERS (500)\n#undef MAX_PLAYERS\n#define MAX_PLAYERS (100)\nnew a[MAX_PLAYERS];",
+			"new a [ ( 100 ) ] ;",
+		},
+	}
+
+	for _, tt := range tests {
+		if got := expandSource(t, tt.input); got != tt.expected {
+			t.Errorf("%s:\nexpected=%q\ngot=     %q", tt.name, tt.expected, got)
+		}
+	}
+}
+
+func TestMacroDefinition(t *testing.T) {
+	pp := New(lexer.New("#define CMD:%0(%1) forward cmd_%0(%1); public cmd_%0(%1)\n"))
+	for tok := pp.NextToken(); tok.Type != token.EOF; tok = pp.NextToken() {
+	}
+
+	macro, ok := pp.macros["CMD"]
+	if !ok {
+		t.Fatalf("macro CMD not defined")
+	}
+	if macro.Pattern != "CMD:%0(%1)" {
+		t.Errorf("macro.Pattern wrong. got=%q", macro.Pattern)
+	}
+	if macro.Substitution != "forward cmd_%0(%1); public cmd_%0(%1)" {
+		t.Errorf("macro.Substitution wrong. got=%q", macro.Substitution)
+	}
+}
+
+func TestMacroRedefinitionWarning(t *testing.T) {
+	pp := New(lexer.New("#define A 1\n#define A 1\n#define A 2\n"))
+	for tok := pp.NextToken(); tok.Type != token.EOF; tok = pp.NextToken() {
+	}
+
+	if len(pp.Warnings()) != 1 {
+		t.Fatalf("expected 1 warning. got=%v", pp.Warnings())
+	}
+}
+
+func TestMacroExpansionLimits(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"#define LOOP LOOP\nLOOP;\n", "macro recursion limit exceeded while expanding LOOP"},
+		{"#define GROW GROW GROW\nGROW;\n", "input line too long after expanding GROW"},
+	}
+
+	for _, tt := range tests {
+		pp := New(lexer.New(tt.input))
+		for tok := pp.NextToken(); tok.Type != token.EOF; tok = pp.NextToken() {
+		}
+
+		if len(pp.Errors()) != 1 || !strings.Contains(pp.Errors()[0], tt.expected) {
+			t.Errorf("%q: expected an error containing %q. got=%v", tt.input, tt.expected, pp.Errors())
+		}
+	}
+}
+
+func TestExpandedPositions(t *testing.T) {
+	input := "#define SendError(%0,%1) SendClientMessage(%0, -1, %1)\n  SendError(playerid, msg);"
+	pp := New(lexer.New(input))
+
+	tests := []struct {
+		literal string
+		line    int
+		column  int
+	}{
+		{"SendClientMessage", 2, 3}, // from the macro: placed at the invocation
+		{"(", 2, 3},
+		{"playerid", 2, 13}, // argument text keeps its own position
+		{",", 2, 3},
+		{"-", 2, 3},
+		{"1", 2, 3},
+		{",", 2, 3},
+		{"msg", 2, 23},
+		{")", 2, 3},
+		{";", 2, 27},
+	}
+
+	for i, tt := range tests {
+		tok := pp.NextToken()
+		if tok.Literal != tt.literal || tok.Line != tt.line || tok.Column != tt.column {
+			t.Errorf("tests[%d] wrong. expected=%q at %d:%d, got=%q at %d:%d",
+				i, tt.literal, tt.line, tt.column, tok.Literal, tok.Line, tok.Column)
+		}
+	}
+}
+
+func TestExpansionOrigins(t *testing.T) {
+	input := `#define CMD:%0(%1) forward cmd_%0(%1); public cmd_%0(%1)
+#define COMMAND:%0(%1) CMD:%0(%1)
+COMMAND:kick(playerid, params) { }`
+	pp := New(lexer.NewFile("commands.inc", input))
+	tokens := readAll(pp)
+
+	// "forward" comes from CMD, which was used in COMMAND's substitution.
+	forward := tokens[0]
+	if forward.Literal != "forward" || forward.Origin == nil {
+		t.Fatalf("expected an expanded forward token. got=%+v", forward)
+	}
+	chain := forward.Origin.Chain()
+	if len(chain) != 2 {
+		t.Fatalf("expected 2 expansions. got=%d", len(chain))
+	}
+	if chain[0].Macro != "CMD:%0(%1)" || chain[0].Definition.Line != 1 {
+		t.Errorf("inner expansion wrong. got=%+v", chain[0])
+	}
+	if chain[1].Macro != "COMMAND:%0(%1)" || chain[1].Definition.Line != 2 || chain[1].Invocation.Line != 3 {
+		t.Errorf("outer expansion wrong. got=%+v", chain[1])
+	}
+
+	expected := "in expansion of macro CMD:%0(%1) from commands.inc:1\nin expansion of macro COMMAND:%0(%1) from commands.inc:2"
+	if forward.Origin.Describe() != expected {
+		t.Errorf("Describe() wrong.\nexpected=%q\ngot=     %q", expected, forward.Origin.Describe())
+	}
+
+	// Argument text was written at the invocation, not in a macro body.
+	for _, tok := range tokens {
+		if tok.Literal == "playerid" && tok.Origin != nil {
+			t.Errorf("argument token has an origin: %s", tok.Origin)
+		}
+	}
+}
+
+func TestExpansionOriginsInAST(t *testing.T) {
+	input := `#define RETURN_ERROR(%0) return SendError(%0)
+public OnPlayerConnect(playerid)
+{
+	RETURN_ERROR(playerid);
+}`
+	program, err := parser.New(New(lexer.NewFile("main.pwn", input))).ParseProgram()
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+
+	decl := program.Statements[0].(*ast.FunctionDeclaration)
+	ret := decl.Body.Statements[0]
+	origin := ast.ExpansionOf(ret)
+	if origin == nil || origin.Macro != "RETURN_ERROR(%0)" || origin.Invocation.Line != 4 {
+		t.Fatalf("return statement origin wrong. got=%+v", origin)
+	}
+	if ast.ExpansionOf(decl) != nil {
+		t.Errorf("function declaration should not have an origin")
+	}
+}
+
+func TestExpansionInParseError(t *testing.T) {
+	input := "#define BROKEN new = 1;\nBROKEN"
+	_, err := parser.New(New(lexer.NewFile("broken.inc", input))).ParseProgram()
+	if err == nil {
+		t.Fatalf("expected a parse error")
+	}
+	if !strings.Contains(err.Error(), "in expansion of macro BROKEN from broken.inc:1") {
+		t.Errorf("error does not name the macro. got=%q", err.Error())
+	}
+}
@@ -0,0 +1,55 @@
+package preprocessor
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+
+	"github.com/Tramposo1312/pawn-parser/token"
+)
+
+// maxBlankLines is the largest gap in line numbers written out as blank
+// lines; a larger one is closed with a #line marker, or a single blank
+// line without markers.
+const maxBlankLines = 8
+
+// WriteExpanded runs the preprocessor to the end of its input and writes
+// the source it produces, like "pawncc -l": every macro substituted,
+// included files in place of their #include lines and inactive branches
+// and directives dropped. Lines the parser handles, such as #pragma, are
+// kept.
+//
+// With lineMarkers set, #file and #line directives are written wherever
+// the output moves to a different file or skips lines, so that reading
+// the output back gives its tokens their original positions.
+func (pp *Preprocessor) WriteExpanded(w io.Writer, lineMarkers bool) error {
+	out := bufio.NewWriter(w)
+	var last token.Position
+	pp.echo = func(at token.Token, text string) {
+		pos := at.Position()
+		gap := pos.Line - last.Line - 1
+		switch {
+		case last.Line == 0 && !lineMarkers:
+		case pos.File == last.File && gap >= 0 && gap <= maxBlankLines:
+			for i := 0; i < gap; i++ {
+				out.WriteString("\n")
+			}
+		case !lineMarkers:
+			out.WriteString("\n")
+		default:
+			if pos.File != last.File {
+				fmt.Fprintf(out, "#file %s\n", quote(pos.File))
+			}
+			// #line numbers its own line.
+			fmt.Fprintf(out, "#line %d\n", pos.Line-1)
+		}
+		out.WriteString(text)
+		out.WriteString("\n")
+		last = pos
+	}
+	defer func() { pp.echo = nil }()
+
+	for pp.NextToken().Type != token.EOF {
+	}
+	return out.Flush()
+}
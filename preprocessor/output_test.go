@@ -0,0 +1,107 @@
+package preprocessor
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/Tramposo1312/pawn-parser/lexer"
+	"github.com/Tramposo1312/pawn-parser/token"
+)
+
+func TestWriteExpanded(t *testing.T) {
+	input := `#define MAX_PLAYERS 500
+#define CMD:%0(%1) forward cmd_%0(%1); public cmd_%0(%1)
+#pragma semicolon 1
+
+#if defined DEBUG
+new debug;
+#endif
+CMD:help(playerid)
+{
+	return MAX_PLAYERS;
+}
+`
+	expected := `#pragma semicolon 1
+
+
+
+
+forward cmd_help(playerid); public cmd_help(playerid)
+{
+return 500;
+}
+`
+
+	pp := New(lexer.New(input))
+	var out bytes.Buffer
+	if err := pp.WriteExpanded(&out, false); err != nil {
+		t.Fatal(err)
+	}
+	if len(pp.Errors()) > 0 {
+		t.Fatalf("preprocessor errors: %v", pp.Errors())
+	}
+	if out.String() != expected {
+		t.Errorf("expected=%q\ngot=     %q", expected, out.String())
+	}
+}
+
+func TestWriteExpandedLineMarkers(t *testing.T) {
+	root := writeFiles(t, map[string]string{
+		"include/colors.inc": "#define COLOR_RED 0xFF0000FF\n\nnative SendClientMessage(playerid, color, const message[]);\n",
+		"main.pwn": `#include <colors>
+
+main()
+{
+
+
+
+
+
+
+
+
+
+
+
+	SendClientMessage(0, COLOR_RED, "hi");
+}
+`,
+	})
+	config := Config{Resolver: NewResolver(filepath.Join(root, "include"))}
+
+	pp, err := Open(filepath.Join(root, "main.pwn"), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err := pp.WriteExpanded(&out, true); err != nil {
+		t.Fatal(err)
+	}
+	if len(pp.Errors()) > 0 {
+		t.Fatalf("preprocessor errors: %v", pp.Errors())
+	}
+
+	// Reading the output back places every token where it was originally.
+	pp, err = Open(filepath.Join(root, "main.pwn"), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	original := readAll(pp)
+	reread := readAll(New(lexer.New(out.String())))
+
+	positions := func(tokens []token.Token) []string {
+		out := []string{}
+		for _, tok := range tokens {
+			if tok.Type != token.INCLUDE && tok.Type != token.LT && tok.Type != token.GT && tok.Literal != "colors" {
+				out = append(out, fmt.Sprintf("%s %s:%d", tok.Literal, tok.File, tok.Line))
+			}
+		}
+		return out
+	}
+	want, got := positions(original), positions(reread)
+	if fmt.Sprint(want) != fmt.Sprint(got) {
+		t.Errorf("positions differ in\n%s\nexpected=%v\ngot=     %v", out.String(), want, got)
+	}
+}
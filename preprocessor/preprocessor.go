@@ -0,0 +1,430 @@
+// preprocessor/preprocessor.go
+
+package preprocessor
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Tramposo1312/pawn-parser/constexpr"
+	"github.com/Tramposo1312/pawn-parser/lexer"
+	"github.com/Tramposo1312/pawn-parser/token"
+)
+
+// Preprocessor runs Pawn's text level preprocessing over a lexer's token
+// stream. Like pawncc it works a logical line at a time: directive lines
+// are executed, lines inside inactive conditional branches are dropped and
+// every other line has its macros expanded before being handed on.
+//
+// A Preprocessor satisfies parser.TokenSource, so the parser sees the
+// expanded program rather than the directives.
+type Preprocessor struct {
+	// files is the stack of open source files; the innermost include is
+	// last.
+	files    []*source
+	resolver *Resolver
+
+	macros       map[string]*Macro
+	conditionals []*conditional
+
+	// symbols holds constants: those pawncc predefines, those given in
+	// the Config, and the ones defined while reading, such as _inc_a_samp
+	// for an included a_samp.inc.
+	symbols map[string]int64
+
+	// time is the compilation time reported by __date and __time.
+	time time.Time
+
+	pending []token.Token
+	eof     token.Token
+	done    bool
+
+	// echo, when set, receives the text of every line passed on, after
+	// expansion. It is used to write the preprocessed source.
+	echo func(at token.Token, text string)
+
+	// needSemicolon mirrors "#pragma semicolon", which changes how a
+	// trailing ';' in a macro pattern matches.
+	needSemicolon bool
+
+	errors   []string
+	warnings []string
+}
+
+// conditional tracks one open #ifdef ... #endif block.
+type conditional struct {
+	Token    token.Token // the directive that opened the block
+	active   bool        // lines in the current branch are emitted
+	taken    bool        // some branch of the block has been active
+	seenElse bool
+}
+
+// New returns a Preprocessor for l with only the definitions pawncc
+// always makes. Use NewWithConfig to add more.
+func New(l *lexer.Lexer) *Preprocessor {
+	l.ReportNewlines(true)
+	pp := &Preprocessor{
+		files:    []*source{{l: l}},
+		macros:   make(map[string]*Macro),
+		symbols:  make(map[string]int64),
+		time:     time.Now(),
+		errors:   []string{},
+		warnings: []string{},
+	}
+	for name, value := range predefined {
+		pp.symbols[name] = value
+	}
+	return pp
+}
+
+// NextToken returns the next token of the expanded program.
+func (pp *Preprocessor) NextToken() token.Token {
+	for len(pp.pending) == 0 {
+		if pp.done {
+			return pp.eof
+		}
+		pp.processLine()
+	}
+
+	tok := pp.pending[0]
+	pp.pending = pp.pending[1:]
+	return tok
+}
+
+func (pp *Preprocessor) Errors() []string {
+	return pp.errors
+}
+
+// Warnings returns problems that do not stop preprocessing, such as a
+// macro being redefined with a different body.
+func (pp *Preprocessor) Warnings() []string {
+	return pp.warnings
+}
+
+// Defined reports whether a macro with the given name (the prefix of its
+// pattern), or a constant such as cellbits or an _inc_ symbol, is
+// currently defined.
+func (pp *Preprocessor) Defined(name string) bool {
+	_, isMacro := pp.macros[name]
+	_, isSymbol := pp.symbols[name]
+	return isMacro || isSymbol || isBuiltin(name)
+}
+
+func (pp *Preprocessor) errorf(tok token.Token, format string, args ...interface{}) {
+	pp.errors = append(pp.errors, diagnostic(tok, format, args...))
+}
+
+func (pp *Preprocessor) warnf(tok token.Token, format string, args ...interface{}) {
+	pp.warnings = append(pp.warnings, diagnostic(tok, format, args...))
+}
+
+// diagnostic formats a message about tok, followed by the macro
+// expansions tok came from.
+func diagnostic(tok token.Token, format string, args ...interface{}) string {
+	msg := fmt.Sprintf("%s: %s", tok.Position(), fmt.Sprintf(format, args...))
+	if tok.Origin != nil {
+		msg += "\n\t" + strings.ReplaceAll(tok.Origin.Describe(), "\n", "\n\t")
+	}
+	return msg
+}
+
+// processLine reads one logical line and either executes it as a
+// directive, drops it, or queues its expansion in pending.
+func (pp *Preprocessor) processLine() {
+	line, eof := pp.readLine()
+	if eof {
+		pp.finish()
+	}
+	if len(line) == 0 {
+		return
+	}
+
+	if isDirective(line[0]) {
+		pp.directive(line[0], line[1:])
+		return
+	}
+
+	if pp.skipping() {
+		return
+	}
+
+	t := pp.expandText(line)
+	if pp.echo != nil {
+		pp.echo(line[0], t.String())
+	}
+	pp.pending = append(pp.pending, t.tokens()...)
+}
+
+// lexer returns the lexer for the file currently being read.
+func (pp *Preprocessor) lexer() *lexer.Lexer {
+	return pp.files[len(pp.files)-1].l
+}
+
+// readLine returns the tokens of the next logical line without its
+// NEWLINE, dropping comments. The end of an included file ends the line
+// and returns to the file that included it; eof is set when the main file
+// is exhausted.
+func (pp *Preprocessor) readLine() (line []token.Token, eof bool) {
+	for {
+		file := pp.files[len(pp.files)-1]
+		tok := file.l.NextToken()
+		file.relocate(&tok)
+		switch tok.Type {
+		case token.NEWLINE:
+			return line, false
+		case token.EOF:
+			if len(pp.files) > 1 {
+				pp.closeFile(true)
+				return line, false
+			}
+			pp.eof = tok
+			return line, true
+		case token.COMMENT:
+			continue
+		}
+		line = append(line, tok)
+	}
+}
+
+// finish is called at the end of input to report unterminated blocks.
+func (pp *Preprocessor) finish() {
+	for _, cond := range pp.conditionals {
+		pp.errorf(cond.Token, "%s without matching #endif", cond.Token.Literal)
+	}
+	pp.conditionals = nil
+	pp.done = true
+}
+
+func (pp *Preprocessor) skipping() bool {
+	return len(pp.conditionals) > 0 && !pp.conditionals[len(pp.conditionals)-1].active
+}
+
+// isDirective reports whether tok starts a directive line. Every directive
+// token type, including the generic token.DIRECTIVE, starts with '#'.
+func isDirective(tok token.Token) bool {
+	return strings.HasPrefix(string(tok.Type), "#")
+}
+
+// directive executes a directive line. Conditional directives are always
+// tracked; everything else is ignored inside an inactive branch.
+func (pp *Preprocessor) directive(tok token.Token, args []token.Token) {
+	switch tok.Literal {
+	case "#if":
+		pp.openConditional(tok, func() bool { return pp.condition(tok, args) })
+		return
+	case "#ifdef", "#ifndef":
+		pp.ifdef(tok, args)
+		return
+	case "#elseif":
+		pp.elseIf(tok, args)
+		return
+	case "#else":
+		pp.elseBranch(tok)
+		return
+	case "#endif":
+		pp.endif(tok)
+		return
+	}
+
+	if pp.skipping() {
+		return
+	}
+
+	switch tok.Literal {
+	case "#define":
+		pp.define(tok, args)
+	case "#undef":
+		if len(args) != 1 || !args[0].IsWord() {
+			pp.errorf(tok, "expected a macro name after #undef")
+			return
+		}
+		delete(pp.macros, args[0].Literal)
+		delete(pp.symbols, args[0].Literal)
+	case "#endinput", "#endscript":
+		pp.endInput(tok)
+	case "#error":
+		pp.errorf(tok, "user error: %s", newText(args).String())
+	case "#warning":
+		pp.warnf(tok, "user warning: %s", newText(args).String())
+	case "#assert":
+		if pp.condition(tok, args) {
+			return
+		}
+		pp.errorf(tok, "assertion failed: %s", newText(args).String())
+	case "#line":
+		pp.line(tok, args)
+	case "#file":
+		pp.file(tok, args)
+	case "#section":
+		// Handled by the parser.
+		pp.passThrough(tok, args)
+	case "#include", "#tryinclude":
+		pp.include(tok, args)
+	case "#emit", "#pragma":
+		// Handled by the parser; pass the line through untouched.
+		if tok.Literal == "#pragma" && len(args) == 2 && args[0].Literal == "semicolon" {
+			pp.needSemicolon = args[1].Literal != "0"
+		}
+		pp.passThrough(tok, args)
+	default:
+		pp.errorf(tok, "unsupported directive %s", tok.Literal)
+	}
+}
+
+// passThrough queues a directive line, unexpanded, for the parser.
+func (pp *Preprocessor) passThrough(tok token.Token, args []token.Token) {
+	pp.pending = append(pp.pending, tok)
+	pp.pending = append(pp.pending, args...)
+	if pp.echo != nil {
+		pp.echo(tok, newText(append([]token.Token{tok}, args...)).String())
+	}
+}
+
+// openConditional starts the block of an #if, #ifdef or #ifndef line. test
+// decides whether the first branch is active; it is not called when the
+// block is nested in an inactive branch.
+func (pp *Preprocessor) openConditional(tok token.Token, test func() bool) {
+	cond := &conditional{Token: tok}
+	if !pp.skipping() {
+		cond.active = test()
+		cond.taken = cond.active
+	} else {
+		// Nested inside an inactive branch: no branch may become active.
+		cond.taken = true
+	}
+	pp.conditionals = append(pp.conditionals, cond)
+}
+
+func (pp *Preprocessor) ifdef(tok token.Token, args []token.Token) {
+	pp.openConditional(tok, func() bool {
+		if len(args) != 1 || !args[0].IsWord() {
+			pp.errorf(tok, "expected a macro name after %s", tok.Literal)
+			return false
+		}
+		return pp.Defined(args[0].Literal) == (tok.Literal == "#ifdef")
+	})
+}
+
+func (pp *Preprocessor) elseIf(tok token.Token, args []token.Token) {
+	if len(pp.conditionals) == 0 {
+		pp.errorf(tok, "#elseif without #if")
+		return
+	}
+	cond := pp.conditionals[len(pp.conditionals)-1]
+	if cond.seenElse {
+		pp.errorf(tok, "#elseif after #else")
+		return
+	}
+	if cond.taken {
+		cond.active = false
+		return
+	}
+	cond.active = pp.condition(tok, args)
+	cond.taken = cond.active
+}
+
+// condition evaluates the expression of an #if or #elseif line. The names
+// tested with "defined" are looked up before macros are substituted in the
+// rest of the line, so that "defined MAX_PLAYERS" asks about the macro
+// rather than its value.
+func (pp *Preprocessor) condition(tok token.Token, args []token.Token) bool {
+	line := []token.Token{}
+	for i := 0; i < len(args); i++ {
+		name, end := definedOperand(args, i)
+		if name == nil {
+			line = append(line, args[i])
+			continue
+		}
+		value := "0"
+		if pp.Defined(name.Literal) {
+			value = "1"
+		}
+		line = append(line, token.Token{Type: token.INT, Literal: value, File: args[i].File, Line: args[i].Line, Column: args[i].Column})
+		i = end
+	}
+
+	value, err := constexpr.Eval(pp.expandLine(line), func(name string) (int64, bool) {
+		if value, ok := pp.symbols[name]; ok {
+			return value, true
+		}
+		return 0, pp.Defined(name)
+	})
+	if err != nil {
+		pp.errorf(tok, "invalid %s condition: %v", tok.Literal, err)
+		return false
+	}
+	return value != 0
+}
+
+// definedOperand recognises "defined NAME" or "defined(NAME)" at args[i],
+// returning the name and the index of the operand's last token.
+func definedOperand(args []token.Token, i int) (*token.Token, int) {
+	if args[i].Type != token.DEFINED {
+		return nil, i
+	}
+	j := i + 1
+	parens := j < len(args) && args[j].Type == token.LPAREN
+	if parens {
+		j++
+	}
+	if j >= len(args) || !args[j].IsWord() {
+		return nil, i
+	}
+	if !parens {
+		return &args[j], j
+	}
+	if j+1 < len(args) && args[j+1].Type == token.RPAREN {
+		return &args[j], j + 1
+	}
+	return nil, i
+}
+
+func (pp *Preprocessor) elseBranch(tok token.Token) {
+	if len(pp.conditionals) == 0 {
+		pp.errorf(tok, "#else without #if")
+		return
+	}
+	cond := pp.conditionals[len(pp.conditionals)-1]
+	if cond.seenElse {
+		pp.errorf(tok, "multiple #else for one #if")
+		return
+	}
+	cond.seenElse = true
+	cond.active = !cond.taken
+	cond.taken = true
+}
+
+func (pp *Preprocessor) endif(tok token.Token) {
+	if len(pp.conditionals) == 0 {
+		pp.errorf(tok, "#endif without #if")
+		return
+	}
+	pp.conditionals = pp.conditionals[:len(pp.conditionals)-1]
+}
+
+// line handles "#line number", which numbers the directive's own line, so
+// the line after it is number+1.
+func (pp *Preprocessor) line(tok token.Token, args []token.Token) {
+	n, err := constexpr.Eval(args, nil)
+	if err != nil {
+		pp.errorf(tok, "invalid #line number: %v", err)
+		return
+	}
+	file := pp.files[len(pp.files)-1]
+	raw := tok.Line - file.lineOffset
+	file.lineOffset = int(n) - raw
+}
+
+// file handles "#file name", which renames the current file in positions.
+func (pp *Preprocessor) file(tok token.Token, args []token.Token) {
+	if len(args) == 0 {
+		pp.errorf(tok, "expected a file name after #file")
+		return
+	}
+	name := newText(args).String()
+	if len(args) == 1 && args[0].Type == token.STRING {
+		name = args[0].Literal
+	}
+	pp.files[len(pp.files)-1].name = name
+}
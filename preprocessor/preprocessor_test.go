@@ -0,0 +1,243 @@
+package preprocessor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Tramposo1312/pawn-parser/ast"
+	"github.com/Tramposo1312/pawn-parser/lexer"
+	"github.com/Tramposo1312/pawn-parser/parser"
+	"github.com/Tramposo1312/pawn-parser/token"
+)
+
+// expandSource runs the preprocessor over input and joins the resulting
+// token literals with single spaces.
+func expandSource(t *testing.T, input string) string {
+	t.Helper()
+	pp := New(lexer.New(input))
+
+	literals := []string{}
+	for tok := pp.NextToken(); tok.Type != token.EOF; tok = pp.NextToken() {
+		literals = append(literals, tok.Literal)
+	}
+
+	if len(pp.Errors()) > 0 {
+		t.Fatalf("preprocessor errors: %v", pp.Errors())
+	}
+	return strings.Join(literals, " ")
+}
+
+func TestObjectMacros(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"#define MAX_PLAYERS 500\nnew x = MAX_PLAYERS;", "new x = 500 ;"},
+		{"#define A B\n#define B 2\nnew x = A;", "new x = 2 ;"},
+		{"#define FOREVER for (;;)\nFOREVER { }", "for ( ; ; ) { }"},
+		{"#define X 1\n#undef X\nnew x = X;", "new x = X ;"},
+		{"#define EMPTY\nEMPTY new x;", "new x ;"},
+		{"#define LONG 1 + \\\n 2\nnew x = LONG;", "new x = 1 + 2 ;"},
+		{"new s[] = \"MAX_PLAYERS\";", "new s [ ] = MAX_PLAYERS ;"},
+	}
+
+	for _, tt := range tests {
+		if got := expandSource(t, tt.input); got != tt.expected {
+			t.Errorf("input %q: expected=%q, got=%q", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestConditionals(t *testing.T) {
+	input := `
+#define DEBUG
+#ifdef DEBUG
+new debug = 1;
+#else
+new debug = 0;
+#endif
+#ifndef DEBUG
+new missing = 1;
+#endif
+#ifdef UNKNOWN
+	#ifdef DEBUG
+	new nested = 1;
+	#else
+	new nested = 2;
+	#endif
+#endif
+`
+
+	expected := "new debug = 1 ;"
+	if got := expandSource(t, input); got != expected {
+		t.Errorf("expected=%q, got=%q", expected, got)
+	}
+}
+
+func TestIfConditions(t *testing.T) {
+	input := `
+#define MAX_PLAYERS (500)
+#define FOO
+#if defined FOO && MAX_PLAYERS > 100
+new a = 1;
+#endif
+#if defined(BAR) || MAX_PLAYERS < 100
+new b = 1;
+#elseif MAX_PLAYERS == 500
+new b = 2;
+#elseif 1
+new b = 3;
+#else
+new b = 4;
+#endif
+#if !defined MAX_PLAYERS
+new c = 1;
+#elseif 0
+new c = 2;
+#else
+new c = 3;
+#endif
+#if 0
+	#if UNDEFINED_SYMBOL > 1
+	new d = 1;
+	#endif
+#endif
+#ifndef BAR
+new e = 1;
+#endif
+`
+
+	expected := "new a = 1 ; new b = 2 ; new c = 3 ; new e = 1 ;"
+	if got := expandSource(t, input); got != expected {
+		t.Errorf("expected=%q, got=%q", expected, got)
+	}
+}
+
+func TestUserDiagnostics(t *testing.T) {
+	input := `#define MAX_PLAYERS 500
+#if MAX_PLAYERS > 1000
+	#error MAX_PLAYERS is too high
+#endif
+#assert MAX_PLAYERS <= 1000
+#warning Don't use this include
+#assert MAX_PLAYERS == 100
+#error Unsupported
+`
+
+	pp := New(lexer.New(input))
+	readAll(pp)
+
+	expectedErrors := []string{
+		"line 7: assertion failed: MAX_PLAYERS == 100",
+		"line 8: user error: Unsupported",
+	}
+	if strings.Join(pp.Errors(), "|") != strings.Join(expectedErrors, "|") {
+		t.Errorf("errors wrong.\nexpected=%q\ngot=     %q", expectedErrors, pp.Errors())
+	}
+	if len(pp.Warnings()) != 1 || pp.Warnings()[0] != "line 6: user warning: Don't use this include" {
+		t.Errorf("warnings wrong. got=%q", pp.Warnings())
+	}
+}
+
+func TestLineAndFile(t *testing.T) {
+	input := `new a;
+#line 100
+new b;
+#file "generated.pwn"
+new c;
+#error here
+`
+
+	pp := New(lexer.NewFile("main.pwn", input))
+	positions := []string{}
+	for _, tok := range readAll(pp) {
+		if tok.Type == token.NEW {
+			positions = append(positions, tok.Position().String())
+		}
+	}
+
+	expected := "main.pwn:1 main.pwn:101 generated.pwn:103"
+	if got := strings.Join(positions, " "); got != expected {
+		t.Errorf("positions wrong. expected=%q, got=%q", expected, got)
+	}
+	if len(pp.Errors()) != 1 || pp.Errors()[0] != "generated.pwn:104: user error: here" {
+		t.Errorf("error position wrong. got=%q", pp.Errors())
+	}
+}
+
+func TestPreprocessorErrors(t *testing.T) {
+	tests := []string{
+		"#ifdef X\nnew x;",
+		"#endif",
+		"#ifdef X\n#else\n#else\n#endif",
+		"#define\n",
+		"#define A A + 1\nnew x = A;",
+		"#define %0 x\n",
+		"#if\n#endif",
+		"#if 1 +\n#endif",
+		"#if UNKNOWN\n#endif",
+		"#elseif 1\n",
+		"#if 1\n#else\n#elseif 1\n#endif",
+	}
+
+	for _, input := range tests {
+		pp := New(lexer.New(input))
+		for tok := pp.NextToken(); tok.Type != token.EOF; tok = pp.NextToken() {
+		}
+		if len(pp.Errors()) == 0 {
+			t.Errorf("expected errors for %q", input)
+		}
+	}
+}
+
+func TestParseExpanded(t *testing.T) {
+	input := `
+#include <a_samp>
+#define MAX_PLAYERS 500
+#define SPAWN_HEALTH 100
+
+public OnPlayerSpawn(playerid)
+{
+#ifdef DEBUG
+	print("spawn");
+#endif
+	#pragma unused playerid
+	return SPAWN_HEALTH + MAX_PLAYERS;
+}
+`
+
+	p := parser.New(New(lexer.New(input)))
+	program, err := p.ParseProgram()
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+
+	if len(program.Statements) != 2 {
+		t.Fatalf("program.Statements does not contain 2 statements. got=%d",
+			len(program.Statements))
+	}
+
+	if _, ok := program.Statements[0].(*ast.IncludeDirective); !ok {
+		t.Fatalf("program.Statements[0] is not ast.IncludeDirective. got=%T",
+			program.Statements[0])
+	}
+
+	decl, ok := program.Statements[1].(*ast.FunctionDeclaration)
+	if !ok {
+		t.Fatalf("program.Statements[1] is not ast.FunctionDeclaration. got=%T",
+			program.Statements[1])
+	}
+
+	if len(decl.Body.Statements) != 2 {
+		t.Fatalf("function body does not contain 2 statements. got=%d",
+			len(decl.Body.Statements))
+	}
+
+	ret, ok := decl.Body.Statements[1].(*ast.ReturnStatement)
+	if !ok {
+		t.Fatalf("Statements[1] is not ast.ReturnStatement. got=%T", decl.Body.Statements[1])
+	}
+	if ret.ReturnValue.String() != "(100 + 500)" {
+		t.Errorf("return value not expanded. got=%s", ret.ReturnValue.String())
+	}
+}
@@ -0,0 +1,95 @@
+// Package symbol resolves the names a program declares into nested
+// scopes.
+package symbol
+
+import "github.com/Tramposo1312/pawn-parser/ast"
+
+// Kind says what a symbol names.
+type Kind int
+
+const (
+	Variable Kind = iota
+	Parameter
+	Function
+	Native
+	Inline
+	Iterator
+	Timer
+)
+
+var kindNames = map[Kind]string{
+	Variable:  "variable",
+	Parameter: "parameter",
+	Function:  "function",
+	Native:    "native",
+	Inline:    "inline function",
+	Iterator:  "iterator",
+	Timer:     "timer",
+}
+
+func (k Kind) String() string {
+	return kindNames[k]
+}
+
+// Symbol is a declared name.
+type Symbol struct {
+	Name  string
+	Kind  Kind
+	Node  ast.Node // the declaration
+	Scope *Scope
+}
+
+// Scope is a region of the program whose declarations are visible to it
+// and to the scopes nested in it. Node is the declaration or statement
+// that opens it: the Program for the global scope, then functions,
+// inline functions and blocks.
+type Scope struct {
+	Parent   *Scope
+	Node     ast.Node
+	Children []*Scope
+
+	symbols map[string]*Symbol
+	order   []*Symbol
+}
+
+func NewScope(parent *Scope, node ast.Node) *Scope {
+	s := &Scope{Parent: parent, Node: node, symbols: make(map[string]*Symbol)}
+	if parent != nil {
+		parent.Children = append(parent.Children, s)
+	}
+	return s
+}
+
+// Define declares name in the scope. It returns false, and the existing
+// symbol, if the scope already declares the name.
+func (s *Scope) Define(name string, kind Kind, node ast.Node) (*Symbol, bool) {
+	if sym, ok := s.symbols[name]; ok {
+		return sym, false
+	}
+	sym := &Symbol{Name: name, Kind: kind, Node: node, Scope: s}
+	s.symbols[name] = sym
+	s.order = append(s.order, sym)
+	return sym, true
+}
+
+// LookupLocal finds a name declared in this scope only.
+func (s *Scope) LookupLocal(name string) (*Symbol, bool) {
+	sym, ok := s.symbols[name]
+	return sym, ok
+}
+
+// Lookup finds a name declared in this scope or the nearest enclosing
+// scope that declares it.
+func (s *Scope) Lookup(name string) (*Symbol, bool) {
+	for scope := s; scope != nil; scope = scope.Parent {
+		if sym, ok := scope.symbols[name]; ok {
+			return sym, true
+		}
+	}
+	return nil, false
+}
+
+// Symbols returns the scope's own symbols in the order they were declared.
+func (s *Scope) Symbols() []*Symbol {
+	return s.order
+}
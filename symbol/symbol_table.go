@@ -0,0 +1,376 @@
+package symbol
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Tramposo1312/pawn-parser/ast"
+	"github.com/Tramposo1312/pawn-parser/token"
+)
+
+// Table holds the scopes of a program and what its callback references
+// resolve to.
+type Table struct {
+	Global *Scope
+
+	scopes   map[ast.Node]*Scope
+	resolved map[*ast.UsingExpression]*Symbol
+	sections []*ast.TextSection
+	texts    []*ast.TextIdentifier
+	modified map[*Symbol]bool
+	errors   []string
+	warnings []string
+}
+
+// Build declares every name in program in its scope. Declarations are
+// visited in order, so like the compiler a name is only visible after it
+// is declared, except at global scope where functions may be used first.
+func Build(program *ast.Program) *Table {
+	t := &Table{
+		scopes:   make(map[ast.Node]*Scope),
+		resolved: make(map[*ast.UsingExpression]*Symbol),
+		modified: make(map[*Symbol]bool),
+		errors:   []string{},
+		warnings: []string{},
+	}
+	t.Global = t.open(nil, program)
+	for _, stmt := range program.Statements {
+		t.declareGlobal(stmt)
+	}
+	for _, stmt := range program.Statements {
+		t.statement(t.Global, stmt)
+	}
+	return t
+}
+
+// Errors returns the references that did not resolve, such as a "using
+// inline" naming no inline function in scope.
+func (t *Table) Errors() []string {
+	return t.errors
+}
+
+// ScopeOf returns the scope a node opens, if it opens one.
+func (t *Table) ScopeOf(node ast.Node) (*Scope, bool) {
+	scope, ok := t.scopes[node]
+	return scope, ok
+}
+
+// Resolve returns the function a "using" expression passes.
+func (t *Table) Resolve(using *ast.UsingExpression) (*Symbol, bool) {
+	sym, ok := t.resolved[using]
+	return sym, ok
+}
+
+// TextSections returns the y_text sections loaded by loadtext
+// declarations, in the order they appear.
+func (t *Table) TextSections() []*ast.TextSection {
+	return t.sections
+}
+
+// Texts returns every y_text string identifier the program references,
+// such as $WELCOME, in the order they appear.
+func (t *Table) Texts() []*ast.TextIdentifier {
+	return t.texts
+}
+
+// Warnings returns the const correctness warnings the open.mp compiler
+// gives: an array parameter of a function that never changes it should
+// be declared const. Public functions are left out, as their parameters
+// are fixed by their callers.
+func (t *Table) Warnings() []string {
+	return t.warnings
+}
+
+func (t *Table) errorf(node ast.Node, format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	if tok, ok := ast.NodeToken(node); ok {
+		msg = fmt.Sprintf("%s: %s", tok.Position(), msg)
+	}
+	t.errors = append(t.errors, msg)
+}
+
+func (t *Table) warnf(node ast.Node, format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	if tok, ok := ast.NodeToken(node); ok {
+		msg = fmt.Sprintf("%s: %s", tok.Position(), msg)
+	}
+	t.warnings = append(t.warnings, msg)
+}
+
+func (t *Table) open(parent *Scope, node ast.Node) *Scope {
+	scope := NewScope(parent, node)
+	t.scopes[node] = scope
+	return scope
+}
+
+// declareGlobal declares the names a top level statement makes visible to
+// the whole program.
+func (t *Table) declareGlobal(stmt ast.Statement) {
+	switch n := stmt.(type) {
+	case *ast.FunctionDeclaration:
+		// State functions share a name; the first declaration is kept.
+		t.Global.Define(n.Name.Value, Function, n)
+	case *ast.CommandDeclaration:
+		t.Global.Define(n.Name.Value, Function, n)
+	case *ast.NativeFunctionDeclaration:
+		t.Global.Define(n.Name.Value, Native, n)
+	case *ast.TimerDeclaration:
+		t.Global.Define(n.Name.Value, Timer, n)
+	case *ast.RemoteFunctionDeclaration:
+		t.Global.Define(n.Name.Value, Function, n)
+	case *ast.ConditionalDirective:
+		for _, branch := range n.Branches {
+			for _, s := range branch.Body {
+				t.declareGlobal(s)
+			}
+		}
+	}
+}
+
+func (t *Table) statement(scope *Scope, stmt ast.Statement) {
+	switch n := stmt.(type) {
+	case *ast.LetStatement:
+		t.expression(scope, n.Value)
+		scope.Define(n.Name.Value, Variable, n)
+	case *ast.IteratorDeclaration:
+		scope.Define(n.Name.Value, Iterator, n)
+	case *ast.FunctionDeclaration:
+		inner := t.function(scope, n, n.Parameters, n.Body)
+		if n.Token.Type != token.PUBLIC {
+			t.checkConstArrays(inner, n.Parameters)
+		}
+	case *ast.CommandDeclaration:
+		t.function(scope, n, n.Parameters, n.Body)
+	case *ast.HookDeclaration:
+		t.function(scope, n, n.Parameters, n.Body)
+	case *ast.TimerDeclaration:
+		t.function(scope, n, n.Parameters, n.Body)
+	case *ast.RemoteFunctionDeclaration:
+		t.function(scope, n, n.Parameters, n.Body)
+	case *ast.LoadTextDeclaration:
+		t.sections = append(t.sections, n.Sections...)
+	case *ast.InlineDeclaration:
+		// An inline function is declared where it is written, and sees
+		// the variables of the function around it.
+		if _, ok := scope.Define(n.Name.Value, Inline, n); !ok {
+			t.errorf(n, "inline function %s already declared in this scope", n.Name.Value)
+		}
+		t.function(scope, n, n.Parameters, n.Body)
+	case *ast.BlockStatement:
+		t.block(scope, n)
+	case *ast.ExpressionStatement:
+		t.expression(scope, n.Expression)
+	case *ast.ReturnStatement:
+		t.expression(scope, n.ReturnValue)
+	case *ast.YieldStatement:
+		t.expression(scope, n.Value)
+	case *ast.InlineReturnStatement:
+		t.expression(scope, n.Value)
+	case *ast.SleepStatement:
+		t.expression(scope, n.Value)
+	case *ast.ExitStatement:
+		t.expression(scope, n.Value)
+	case *ast.AssertStatement:
+		t.expression(scope, n.Condition)
+	case *ast.IfStatement:
+		t.expression(scope, n.Condition)
+		t.block(scope, n.Consequence)
+		t.block(scope, n.Alternative)
+	case *ast.WhileStatement:
+		t.expression(scope, n.Condition)
+		t.block(scope, n.Body)
+	case *ast.ForStatement:
+		inner := t.open(scope, n)
+		if n.Init != nil {
+			t.statement(inner, n.Init)
+		}
+		t.expression(inner, n.Condition)
+		if n.Update != nil {
+			t.statement(inner, n.Update)
+		}
+		t.block(inner, n.Body)
+	case *ast.ForeachStatement:
+		inner := t.open(scope, n)
+		t.expression(scope, n.Iterator)
+		if n.Declared {
+			inner.Define(n.Variable.Value, Variable, n)
+		}
+		t.block(inner, n.Body)
+	case *ast.ConditionalDirective:
+		// Only one branch is compiled, so each declares into the
+		// enclosing scope.
+		for _, branch := range n.Branches {
+			for _, s := range branch.Body {
+				t.statement(scope, s)
+			}
+		}
+	}
+}
+
+// function opens the scope of a function-like declaration, holding its
+// parameters, and declares its body in it.
+func (t *Table) function(scope *Scope, node ast.Node, params []*ast.Identifier, body *ast.BlockStatement) *Scope {
+	inner := t.open(scope, node)
+	for _, param := range params {
+		inner.Define(parameterName(param.Value), Parameter, param)
+	}
+	t.block(inner, body)
+	return inner
+}
+
+// checkConstArrays warns about the array parameters declared in scope
+// that are neither const nor changed by the function.
+func (t *Table) checkConstArrays(scope *Scope, params []*ast.Identifier) {
+	for _, param := range params {
+		if !strings.Contains(param.Value, "[") || strings.HasPrefix(param.Value, "const ") {
+			continue
+		}
+		sym, ok := scope.LookupLocal(parameterName(param.Value))
+		if ok && sym.Node == param && !t.modified[sym] {
+			t.warnf(param, "possibly a \"const\" array argument was intended: \"%s\"", sym.Name)
+		}
+	}
+}
+
+// modify records that exp, or the array it indexes, may be changed.
+func (t *Table) modify(scope *Scope, exp ast.Expression) {
+	for {
+		index, ok := exp.(*ast.IndexExpression)
+		if !ok {
+			break
+		}
+		exp = index.Left
+	}
+	if ident, ok := exp.(*ast.Identifier); ok {
+		if sym, ok := scope.Lookup(ident.Value); ok {
+			t.modified[sym] = true
+		}
+	}
+}
+
+// passed records an array given to a call. The callee may change it
+// unless it declares that parameter const.
+func (t *Table) passed(scope *Scope, call *ast.CallExpression, i int) {
+	if ident, ok := call.Function.(*ast.Identifier); ok {
+		if sym, ok := scope.Lookup(ident.Value); ok {
+			var params []*ast.Identifier
+			switch n := sym.Node.(type) {
+			case *ast.FunctionDeclaration:
+				params = n.Parameters
+			case *ast.NativeFunctionDeclaration:
+				params = n.Parameters
+			}
+			if i < len(params) && strings.HasPrefix(params[i].Value, "const ") {
+				return
+			}
+		}
+	}
+	t.modify(scope, call.Arguments[i])
+}
+
+func (t *Table) block(scope *Scope, block *ast.BlockStatement) {
+	if block == nil {
+		return
+	}
+	inner := t.open(scope, block)
+	for _, stmt := range block.Statements {
+		t.statement(inner, stmt)
+	}
+}
+
+func (t *Table) expression(scope *Scope, exp ast.Expression) {
+	switch n := exp.(type) {
+	case *ast.UsingExpression:
+		t.using(scope, n)
+	case *ast.TextIdentifier:
+		t.texts = append(t.texts, n)
+	case *ast.PrefixExpression:
+		if n.Operator == "++" || n.Operator == "--" {
+			t.modify(scope, n.Right)
+		}
+		t.expression(scope, n.Right)
+	case *ast.InfixExpression:
+		if isAssignment(n.Operator) {
+			t.modify(scope, n.Left)
+		}
+		t.expression(scope, n.Left)
+		t.expression(scope, n.Right)
+	case *ast.PostfixExpression:
+		t.modify(scope, n.Left)
+		t.expression(scope, n.Left)
+	case *ast.CommaExpression:
+		for _, e := range n.Expressions {
+			t.expression(scope, e)
+		}
+	case *ast.CallExpression:
+		t.expression(scope, n.Function)
+		for i, arg := range n.Arguments {
+			t.passed(scope, n, i)
+			t.expression(scope, arg)
+		}
+	case *ast.IndexExpression:
+		t.expression(scope, n.Left)
+		t.expression(scope, n.Index)
+	case *ast.TimerCallExpression:
+		t.expression(scope, n.Delay)
+		for _, arg := range n.Arguments {
+			t.modify(scope, arg)
+			t.expression(scope, arg)
+		}
+	case *ast.StopExpression:
+		t.expression(scope, n.Timer)
+	case *ast.VarArgsExpression:
+		t.expression(scope, n.Start)
+	case *ast.StaticAssertExpression:
+		t.expression(scope, n.Condition)
+		t.expression(scope, n.Message)
+	case *ast.FunctionLiteral:
+		t.function(scope, n, n.Parameters, n.Body)
+	}
+}
+
+// using resolves the function a "using" expression passes. An inline
+// function has to be in scope; a public one may be declared in another
+// file, so it is only recorded when found.
+func (t *Table) using(scope *Scope, using *ast.UsingExpression) {
+	sym, ok := scope.Lookup(using.Name.Value)
+	if using.Kind != "inline" {
+		if ok && sym.Kind == Function {
+			t.resolved[using] = sym
+		}
+		return
+	}
+
+	switch {
+	case !ok:
+		t.errorf(using, "undefined inline function %s", using.Name.Value)
+	case sym.Kind != Inline:
+		t.errorf(using, "%s is a %s, not an inline function", using.Name.Value, sym.Kind)
+	default:
+		t.resolved[using] = sym
+	}
+}
+
+// isAssignment reports whether an infix operator is '=' or a compound
+// assignment such as "+=".
+func isAssignment(operator string) bool {
+	switch operator {
+	case "=", "+=", "-=", "*=", "/=", "%=", "&=", "|=", "^=", "<<=", ">>=", ">>>=":
+		return true
+	}
+	return false
+}
+
+// parameterName strips the const or reference marker, tag and array
+// dimensions from a parameter as the parser records it, e.g.
+// "const string:text[]".
+func parameterName(param string) string {
+	if i := strings.IndexByte(param, '['); i >= 0 {
+		param = param[:i]
+	}
+	if i := strings.LastIndexByte(param, ':'); i >= 0 {
+		param = param[i+1:]
+	}
+	param = strings.TrimPrefix(param, "const ")
+	return strings.TrimPrefix(param, "&")
+}
@@ -0,0 +1,187 @@
+package symbol
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Tramposo1312/pawn-parser/ast"
+	"github.com/Tramposo1312/pawn-parser/lexer"
+	"github.com/Tramposo1312/pawn-parser/parser"
+)
+
+func build(t *testing.T, input string) (*ast.Program, *Table) {
+	t.Helper()
+	program, err := parser.New(lexer.New(input)).ParseProgram()
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	return program, Build(program)
+}
+
+func TestGlobalSymbols(t *testing.T) {
+	_, table := build(t, `
+native print(text);
+new count = 0;
+timer Save[1000]() { }
+foreign void:Remote(playerid);
+Helper() { return 1; }
+main() { return Helper(); }
+`)
+
+	tests := []struct {
+		name string
+		kind Kind
+	}{
+		{"print", Native},
+		{"count", Variable},
+		{"Save", Timer},
+		{"Remote", Function},
+		{"Helper", Function},
+		{"main", Function},
+	}
+	for _, tt := range tests {
+		sym, ok := table.Global.LookupLocal(tt.name)
+		if !ok {
+			t.Errorf("%s not declared at global scope", tt.name)
+			continue
+		}
+		if sym.Kind != tt.kind {
+			t.Errorf("%s is a %s. expected %s", tt.name, sym.Kind, tt.kind)
+		}
+	}
+}
+
+func TestInlineScoping(t *testing.T) {
+	program, table := build(t, `
+LoadAccount(playerid)
+{
+	new attempts = 0;
+	inline OnDataLoaded(string:name[], level, Float:pos[Tag:MAX + 1])
+	{
+		attempts++;
+	}
+	MySQL_TQueryInline(handle, using inline OnDataLoaded, "SELECT 1");
+	return 1;
+}
+`)
+	if len(table.Errors()) > 0 {
+		t.Fatalf("unexpected errors: %v", table.Errors())
+	}
+
+	decl := program.Statements[0].(*ast.FunctionDeclaration)
+	inline := decl.Body.Statements[1].(*ast.InlineDeclaration)
+
+	// The inline function's parameters are its own, and it sees the
+	// enclosing function's variables and parameters.
+	scope, ok := table.ScopeOf(inline.Body)
+	if !ok {
+		t.Fatalf("inline body has no scope")
+	}
+	for _, name := range []string{"attempts", "playerid", "name", "level", "pos", "LoadAccount"} {
+		if _, ok := scope.Lookup(name); !ok {
+			t.Errorf("%s not visible inside the inline function", name)
+		}
+	}
+	if sym, _ := scope.Lookup("name"); sym != nil && sym.Kind != Parameter {
+		t.Errorf("name is a %s. expected parameter", sym.Kind)
+	}
+
+	// The inline function is not visible outside the function.
+	if _, ok := table.Global.Lookup("OnDataLoaded"); ok {
+		t.Errorf("inline function visible at global scope")
+	}
+	if _, ok := table.Global.Lookup("level"); ok {
+		t.Errorf("inline parameter visible at global scope")
+	}
+
+	call := decl.Body.Statements[2].(*ast.ExpressionStatement).Expression.(*ast.CallExpression)
+	using := call.Arguments[1].(*ast.UsingExpression)
+	sym, ok := table.Resolve(using)
+	if !ok {
+		t.Fatalf("using inline OnDataLoaded not resolved")
+	}
+	if sym.Node != inline {
+		t.Errorf("resolved to the wrong declaration. got=%T", sym.Node)
+	}
+}
+
+func TestUsingResolutionErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`f() { Call(using inline Missing); }`, "undefined inline function Missing"},
+		{`f() { Call(using inline Later); inline Later() { } }`, "undefined inline function Later"},
+		{`f() { inline A() { } } g() { Call(using inline A); }`, "undefined inline function A"},
+		{`f() { new x = 0; Call(using inline x); }`, "x is a variable, not an inline function"},
+		{`f() { inline A() { } inline A() { } }`, "inline function A already declared"},
+	}
+
+	for _, tt := range tests {
+		_, table := build(t, tt.input)
+		if len(table.Errors()) != 1 || !strings.Contains(table.Errors()[0], tt.expected) {
+			t.Errorf("%q: expected error %q. got=%v", tt.input, tt.expected, table.Errors())
+		}
+	}
+
+	_, table := build(t, `f() { Call(using public OnElsewhere); }`)
+	if len(table.Errors()) != 0 {
+		t.Errorf("using public reported an error: %v", table.Errors())
+	}
+}
+
+func TestTextReferences(t *testing.T) {
+	_, table := build(t, `
+ShowWelcome(playerid)
+{
+	loadtext core[messages], core[errors];
+	if (IsAdmin(playerid)) {
+		Text_Send(playerid, $ADMIN_WELCOME);
+	}
+	Text_Send(playerid, $WELCOME, GetName(playerid));
+	defer Remind[1000](playerid, $REMINDER);
+}
+`)
+
+	names := []string{}
+	for _, text := range table.Texts() {
+		names = append(names, text.Name)
+	}
+	if got := strings.Join(names, " "); got != "ADMIN_WELCOME WELCOME REMINDER" {
+		t.Errorf("texts wrong. got=%q", got)
+	}
+
+	sections := []string{}
+	for _, section := range table.TextSections() {
+		sections = append(sections, section.File+"["+section.Section+"]")
+	}
+	if got := strings.Join(sections, " "); got != "core[messages] core[errors]" {
+		t.Errorf("sections wrong. got=%q", got)
+	}
+}
+
+func TestConstArrayWarnings(t *testing.T) {
+	_, table := build(t, `
+native SendClientMessage(playerid, color, const message[]);
+native strcat(dest[], const source[], maxlength);
+
+Show(playerid, text[]) { SendClientMessage(playerid, -1, text); }
+ShowConst(playerid, const text[]) { SendClientMessage(playerid, -1, text); }
+Clear(text[]) { text[0] = 0; }
+Append(text[], more[]) { strcat(text, more, 32); }
+Bump(counts[], &total) { counts[0]++; total++; }
+Unknown(text[]) { Elsewhere(text); }
+public OnText(playerid, text[]) { return 1; }
+`)
+	if len(table.Errors()) > 0 {
+		t.Fatalf("unexpected errors: %v", table.Errors())
+	}
+
+	expected := []string{
+		`line 5: possibly a "const" array argument was intended: "text"`,
+		`line 8: possibly a "const" array argument was intended: "more"`,
+	}
+	if got := table.Warnings(); strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("warnings wrong.\nexpected=%q\ngot=     %q", expected, got)
+	}
+}
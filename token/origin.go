@@ -0,0 +1,58 @@
+package token
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Position is a place in a source file.
+type Position struct {
+	File   string
+	Line   int
+	Column int
+}
+
+func (p Position) String() string {
+	if p.File != "" {
+		return fmt.Sprintf("%s:%d", p.File, p.Line)
+	}
+	return fmt.Sprintf("line %d", p.Line)
+}
+
+// Position returns where the token is in its source file.
+func (t Token) Position() Position {
+	return Position{File: t.File, Line: t.Line, Column: t.Column}
+}
+
+// Origin describes the macro expansion a token came from. When the macro
+// was itself used inside another macro's substitution, Parent is that
+// outer expansion.
+type Origin struct {
+	Macro      string   // the macro's pattern, e.g. "CMD:%0(%1)"
+	Definition Position // the #define
+	Invocation Position // where the macro was used
+	Parent     *Origin
+}
+
+func (o *Origin) String() string {
+	return fmt.Sprintf("in expansion of macro %s from %s", o.Macro, o.Definition)
+}
+
+// Chain returns the expansions a token went through, innermost first.
+func (o *Origin) Chain() []*Origin {
+	chain := []*Origin{}
+	for ; o != nil; o = o.Parent {
+		chain = append(chain, o)
+	}
+	return chain
+}
+
+// Describe returns one line per expansion in the chain, innermost first,
+// suitable for appending to a diagnostic.
+func (o *Origin) Describe() string {
+	lines := []string{}
+	for _, origin := range o.Chain() {
+		lines = append(lines, origin.String())
+	}
+	return strings.Join(lines, "\n")
+}
@@ -9,12 +9,23 @@ type Token struct {
 	Literal string
 	Line    int
 	Column  int
+	File    string // set when the lexer was given a file name
+
+	// Origin is set on tokens produced by macro substitution. Line and
+	// Column then give the place the macro was used.
+	Origin *Origin
+
+	// Continued is set on the first token after a backslash line
+	// continuation. It belongs to the same logical line as the token
+	// before it even though its Line is higher.
+	Continued bool
 }
 
 const (
 	ILLEGAL TokenType = "ILLEGAL"
 	EOF     TokenType = "EOF"
 	COMMENT TokenType = "COMMENT"
+	NEWLINE TokenType = "NEWLINE" // only reported when the lexer is asked to
 
 	// Identifiers + literals
 	IDENT  = "IDENT" // add, foobar, x, y, ...
@@ -22,6 +33,7 @@ const (
 	FLOAT  = "FLOAT"
 	CHAR   = "CHAR" // 'a'
 	STRING = "STRING"
+	TEXTID = "TEXTID" // y_text string identifier, $WELCOME
 
 	// Operators
 	PLUS           = "+"
@@ -34,6 +46,7 @@ const (
 	XOR            = "^"
 	SHL            = "<<"
 	SHR            = ">>"
+	SHRU           = ">>>"
 	AND_NOT        = "&^"
 	ADD_ASSIGN     = "+="
 	SUB_ASSIGN     = "-="
@@ -119,10 +132,8 @@ const (
 	STATE      = "state"
 	EXIT       = "exit"
 	TIMER      = "timer"
-	ITERFUNC   = "iterfunc"
 	HOOK       = "hook"
 	INLINE     = "inline"
-	MASTER     = "master"
 	TASK       = "task"
 	PTASK      = "ptask"
 	FOREIGN    = "foreign"
@@ -133,11 +144,26 @@ const (
 	LOADTEXT   = "loadtext"
 
 	// Preprocessor directives
-	DIRECTIVE = "#"
-	INCLUDE   = "#include"
-	DEFINE    = "#define"
-	IFDEF     = "#ifdef"
-	ENDIF     = "#endif"
+	DIRECTIVE   = "#"
+	INCLUDE     = "#include"
+	TRYINCLUDE  = "#tryinclude"
+	DEFINE      = "#define"
+	HASH_IF     = "#if"
+	IFDEF       = "#ifdef"
+	IFNDEF      = "#ifndef"
+	ELSEIF      = "#elseif"
+	HASH_ELSE   = "#else"
+	ENDIF       = "#endif"
+	ENDINPUT    = "#endinput"
+	UNDEF       = "#undef"
+	ERROR       = "#error"
+	WARNING     = "#warning"
+	HASH_ASSERT = "#assert"
+	LINE        = "#line"
+	FILE        = "#file"
+	SECTION     = "#section"
+	EMIT        = "#emit"
+	PRAGMA      = "#pragma"
 
 	// Comparison
 	EQ  = "=="
@@ -149,47 +175,52 @@ const (
 )
 
 var keywords = map[string]TokenType{
-	"break":      BREAK,
-	"case":       CASE,
-	"const":      CONST,
-	"continue":   CONTINUE,
-	"default":    DEFAULT,
-	"do":         DO,
-	"else":       ELSE,
-	"enum":       ENUM,
-	"for":        FOR,
-	"goto":       GOTO,
-	"if":         IF,
-	"new":        NEW,
-	"return":     RETURN,
-	"sizeof":     SIZEOF,
-	"static":     STATIC,
-	"switch":     SWITCH,
-	"while":      WHILE,
-	"assert":     ASSERT,
-	"defined":    DEFINED,
-	"forward":    FORWARD,
-	"native":     NATIVE,
-	"operator":   OPERATOR,
-	"public":     PUBLIC,
-	"stock":      STOCK,
-	"tagof":      TAGOF,
-	"char":       CHAR_,
-	"float":      FLOAT_,
-	"bool":       BOOL,
-	"void":       VOID,
-	"true":       TRUE,
-	"false":      FALSE,
-	"null":       NULL,
+	"break":    BREAK,
+	"case":     CASE,
+	"const":    CONST,
+	"continue": CONTINUE,
+	"default":  DEFAULT,
+	"do":       DO,
+	"else":     ELSE,
+	"enum":     ENUM,
+	"for":      FOR,
+	"goto":     GOTO,
+	"if":       IF,
+	"new":      NEW,
+	"return":   RETURN,
+	"sizeof":   SIZEOF,
+	"static":   STATIC,
+	"switch":   SWITCH,
+	"while":    WHILE,
+	"assert":   ASSERT,
+	"defined":  DEFINED,
+	"forward":  FORWARD,
+	"native":   NATIVE,
+	"operator": OPERATOR,
+	"public":   PUBLIC,
+	"stock":    STOCK,
+	"tagof":    TAGOF,
+	"char":     CHAR_,
+	"float":    FLOAT_,
+	"bool":     BOOL,
+	"void":     VOID,
+	"true":     TRUE,
+	"false":    FALSE,
+	"null":     NULL,
+	"sleep":    SLEEP,
+	"state":    STATE,
+	"exit":     EXIT,
+	"function": FUNCTION,
+}
+
+// ysiKeywords are the words YSI's macros make into keywords. Pawn does not
+// reserve them, so the lexer returns them as identifiers and the parser
+// decides from the context whether they are used as keywords.
+var ysiKeywords = map[string]TokenType{
 	"foreach":    FOREACH,
-	"sleep":      SLEEP,
-	"state":      STATE,
-	"exit":       EXIT,
 	"timer":      TIMER,
-	"iterfunc":   ITERFUNC,
 	"hook":       HOOK,
 	"inline":     INLINE,
-	"master":     MASTER,
 	"task":       TASK,
 	"ptask":      PTASK,
 	"foreign":    FOREIGN,
@@ -198,7 +229,6 @@ var keywords = map[string]TokenType{
 	"using":      USING,
 	"yield":      YIELD,
 	"loadtext":   LOADTEXT,
-	"function":   FUNCTION,
 }
 
 func LookupIdent(ident string) TokenType {
@@ -207,3 +237,69 @@ func LookupIdent(ident string) TokenType {
 	}
 	return IDENT
 }
+
+// LookupYSIKeyword returns the token type of a YSI keyword, or IDENT for
+// any other word.
+func LookupYSIKeyword(ident string) TokenType {
+	if tok, ok := ysiKeywords[ident]; ok {
+		return tok
+	}
+	return IDENT
+}
+
+var directives = map[string]TokenType{
+	"include":    INCLUDE,
+	"tryinclude": TRYINCLUDE,
+	"define":     DEFINE,
+	"undef":      UNDEF,
+	"if":         HASH_IF,
+	"ifdef":      IFDEF,
+	"ifndef":     IFNDEF,
+	"elseif":     ELSEIF,
+	"else":       HASH_ELSE,
+	"endif":      ENDIF,
+	"endinput":   ENDINPUT,
+	"endscript":  ENDINPUT,
+	"error":      ERROR,
+	"warning":    WARNING,
+	"assert":     HASH_ASSERT,
+	"line":       LINE,
+	"file":       FILE,
+	"section":    SECTION,
+	"emit":       EMIT,
+	"pragma":     PRAGMA,
+}
+
+// LookupDirective returns the token type for a directive name given
+// without its '#', or DIRECTIVE for one the lexer does not know.
+func LookupDirective(name string) TokenType {
+	if tok, ok := directives[name]; ok {
+		return tok
+	}
+	return DIRECTIVE
+}
+
+// Text returns the token as it is written in the source. The lexer strips
+// the quotes from string literals.
+func (t Token) Text() string {
+	if t.Type == STRING {
+		return `"` + t.Literal + `"`
+	}
+	return t.Literal
+}
+
+// End returns the column just after the token.
+func (t Token) End() int {
+	return t.Column + len(t.Text())
+}
+
+// IsWord reports whether the token is an identifier or keyword, i.e.
+// something that can name a macro, a pragma or an opcode; opcodes such as
+// "const.pri" and "break" are lexed as keywords.
+func (t Token) IsWord() bool {
+	if t.Literal == "" {
+		return false
+	}
+	ch := t.Literal[0]
+	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch == '_' || ch == '@'
+}